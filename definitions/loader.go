@@ -2,7 +2,11 @@ package definitions
 
 import (
 	"embed"
+	"fmt"
+	"io/fs"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
@@ -10,31 +14,81 @@ import (
 //go:embed *.yaml
 var definitionFiles embed.FS
 
+// LoadError records a definition file that could not be loaded.
+type LoadError struct {
+	File string
+	Err  error
+}
+
+func (e LoadError) Error() string {
+	return fmt.Sprintf("%s: %v", e.File, e.Err)
+}
+
+func (e LoadError) Unwrap() error {
+	return e.Err
+}
+
+// LoadErrors is returned alongside the definitions that did load when one
+// or more files fail. Use errors.As to get at the individual failures.
+type LoadErrors []LoadError
+
+func (e LoadErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return "loading definitions: " + strings.Join(msgs, "; ")
+}
+
+// LoadEmbedded loads the definitions bundled with this package.
 func LoadEmbedded() ([]*Definition, error) {
-	entries, err := definitionFiles.ReadDir(".")
+	return LoadFromFS(definitionFiles)
+}
+
+// LoadFromFS loads every .yaml definition in the root of fsys, sorted by name.
+// Use it with os.DirFS or your own embed.FS to add definitions alongside the
+// embedded set.
+//
+// A file that can't be read or parsed doesn't stop the others loading: the
+// definitions that did load are returned with a LoadErrors error naming
+// each file that failed.
+func LoadFromFS(fsys fs.FS) ([]*Definition, error) {
+	entries, err := fs.ReadDir(fsys, ".")
 	if err != nil {
 		return nil, err
 	}
 
 	var defs []*Definition
+	var errs LoadErrors
 	for _, entry := range entries {
 		if entry.IsDir() || filepath.Ext(entry.Name()) != ".yaml" {
 			continue
 		}
 
-		data, err := definitionFiles.ReadFile(entry.Name())
+		data, err := fs.ReadFile(fsys, entry.Name())
 		if err != nil {
-			return nil, err
+			errs = append(errs, LoadError{File: entry.Name(), Err: err})
+			continue
 		}
 
 		var def Definition
 		if err := yaml.Unmarshal(data, &def); err != nil {
-			return nil, err
+			errs = append(errs, LoadError{File: entry.Name(), Err: err})
+			continue
 		}
 
 		defs = append(defs, &def)
 	}
 
+	// Directory order varies between filesystems; sort so callers see the
+	// same order everywhere
+	sort.Slice(defs, func(i, j int) bool {
+		return defs[i].Name < defs[j].Name
+	})
+
+	if len(errs) > 0 {
+		return defs, errs
+	}
 	return defs, nil
 }
 
@@ -0,0 +1,114 @@
+package definitions
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadFromFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"custom.yaml":       {Data: []byte("name: custom\nbinary: custom\ncommands:\n  install:\n    base: [install]\n")},
+		"README.md":         {Data: []byte("not a definition")},
+		"nested/other.yaml": {Data: []byte("name: other\n")},
+	}
+
+	defs, err := LoadFromFS(fsys)
+	if err != nil {
+		t.Fatalf("LoadFromFS failed: %v", err)
+	}
+	if len(defs) != 1 {
+		t.Fatalf("got %d definitions, want 1", len(defs))
+	}
+	if defs[0].Name != "custom" {
+		t.Errorf("got name %q, want %q", defs[0].Name, "custom")
+	}
+	if _, ok := defs[0].Commands["install"]; !ok {
+		t.Error("expected install command to be loaded")
+	}
+}
+
+func TestLoadFromFSInvalidYAML(t *testing.T) {
+	fsys := fstest.MapFS{
+		"broken.yaml": {Data: []byte("name: [unclosed\n")},
+		"good.yaml":   {Data: []byte("name: good\nbinary: good\n")},
+	}
+
+	defs, err := LoadFromFS(fsys)
+	if err == nil {
+		t.Fatal("expected error for invalid YAML, got nil")
+	}
+
+	var loadErrs LoadErrors
+	if !errors.As(err, &loadErrs) {
+		t.Fatalf("expected LoadErrors, got %T: %v", err, err)
+	}
+	if len(loadErrs) != 1 || loadErrs[0].File != "broken.yaml" {
+		t.Errorf("got errors %v, want one for broken.yaml", loadErrs)
+	}
+
+	// The valid file still loads
+	if len(defs) != 1 || defs[0].Name != "good" {
+		t.Errorf("got %d definitions, want only good", len(defs))
+	}
+}
+
+func TestLoadEmbedded(t *testing.T) {
+	defs, err := LoadEmbedded()
+	if err != nil {
+		t.Fatalf("LoadEmbedded failed: %v", err)
+	}
+	if len(defs) == 0 {
+		t.Error("expected embedded definitions")
+	}
+}
+
+func TestLoadEmbeddedStableOrder(t *testing.T) {
+	defs, err := LoadEmbedded()
+	if err != nil {
+		t.Fatalf("LoadEmbedded failed: %v", err)
+	}
+	for i := 1; i < len(defs); i++ {
+		if defs[i-1].Name >= defs[i].Name {
+			t.Errorf("definitions not sorted: %q before %q", defs[i-1].Name, defs[i].Name)
+		}
+	}
+}
+
+func TestLoadEmbeddedWebsites(t *testing.T) {
+	defs, err := LoadEmbedded()
+	if err != nil {
+		t.Fatalf("LoadEmbedded failed: %v", err)
+	}
+	for _, def := range defs {
+		if !strings.HasPrefix(def.Website, "https://") {
+			t.Errorf("%s: website %q is not an https URL", def.Name, def.Website)
+		}
+		if def.Issues != "" && !strings.HasPrefix(def.Issues, "https://") {
+			t.Errorf("%s: issues %q is not an https URL", def.Name, def.Issues)
+		}
+	}
+}
+
+func TestConditionalFlagsFromYAML(t *testing.T) {
+	def, err := LoadFromBytes([]byte(`
+name: testpkg
+binary: testpkg
+commands:
+  install:
+    base: [install]
+    conditional_flags:
+      - env_var: CI
+        flag: --yes
+`))
+	if err != nil {
+		t.Fatalf("LoadFromBytes failed: %v", err)
+	}
+
+	expected := []ConditionalFlag{{EnvVar: "CI", Flag: "--yes"}}
+	if got := def.Commands["install"].ConditionalFlags; !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %+v, want %+v", got, expected)
+	}
+}
@@ -1,17 +1,30 @@
 package definitions
 
+import (
+	"fmt"
+	"regexp"
+)
+
 type Definition struct {
-	Name             string              `yaml:"name"`
-	Ecosystem        string              `yaml:"ecosystem"`
-	Binary           string              `yaml:"binary"`
-	Version          string              `yaml:"version,omitempty"`
-	Status           string              `yaml:"status,omitempty"`
-	MinTested        string              `yaml:"min_tested,omitempty"`
-	MaxTested        string              `yaml:"max_tested,omitempty"`
-	Detection        Detection           `yaml:"detection"`
-	VersionDetection VersionDetection    `yaml:"version_detection,omitempty"`
-	Commands         map[string]Command  `yaml:"commands"`
-	Capabilities     []string            `yaml:"capabilities"`
+	Name               string             `yaml:"name"`
+	Ecosystem          string             `yaml:"ecosystem"`
+	Binary             string             `yaml:"binary"`
+	BinaryAlternatives []string           `yaml:"binary_alternatives,omitempty"` // project-local executables (./gradlew) used instead of binary when present
+	Version            string             `yaml:"version,omitempty"`
+	Status             string             `yaml:"status,omitempty"`
+	MinTested          string             `yaml:"min_tested,omitempty"`
+	MaxTested          string             `yaml:"max_tested,omitempty"`
+	Platform           []string           `yaml:"platform,omitempty"` // runtime.GOOS values the manager runs on; empty means any
+	Website            string             `yaml:"website,omitempty"`  // documentation home page, shown in errors
+	Issues             string             `yaml:"issues,omitempty"`   // where to report bugs in the manager itself
+	Detection          Detection          `yaml:"detection"`
+	VersionDetection   VersionDetection   `yaml:"version_detection,omitempty"`
+	Commands           map[string]Command `yaml:"commands"`
+	Capabilities       []string           `yaml:"capabilities"`
+
+	// SafeExtraArgs maps an operation to the flags CommandInput.Extra may
+	// contain for it. Operations without an entry accept any extra args.
+	SafeExtraArgs map[string][]string `yaml:"safe_extra_args,omitempty"`
 }
 
 type Detection struct {
@@ -24,7 +37,7 @@ type Detection struct {
 type FileCheck struct {
 	File    string `yaml:"file"`
 	Exists  bool   `yaml:"exists,omitempty"`
-	Match   string `yaml:"match,omitempty"`
+	Match   string `yaml:"match,omitempty"` // regex the file's content must match for manifest detection
 	Version string `yaml:"version,omitempty"`
 }
 
@@ -34,25 +47,97 @@ type VersionDetection struct {
 }
 
 type Command struct {
-	Base          []string            `yaml:"base"`
-	BaseOverrides map[string][]string `yaml:"base_overrides,omitempty"` // flag name -> replacement base
-	Args          map[string]Arg      `yaml:"args,omitempty"`
-	Flags         map[string]Flag     `yaml:"flags,omitempty"`
-	DefaultFlags  []string            `yaml:"default_flags,omitempty"`
-	ExitCodes     map[int]string      `yaml:"exit_codes,omitempty"`
-	Then          []Command           `yaml:"then,omitempty"` // commands to run after this one
-	Extract       *Extract            `yaml:"extract,omitempty"`
+	Binary           string              `yaml:"binary,omitempty"` // replaces the definition's binary for this command (e.g. apt list for apt-get)
+	Base             []string            `yaml:"base"`
+	BaseOverrides    map[string][]string `yaml:"base_overrides,omitempty"` // flag or arg name -> replacement base
+	Args             map[string]Arg      `yaml:"args,omitempty"`
+	Flags            map[string]Flag     `yaml:"flags,omitempty"`
+	DefaultFlags     []string            `yaml:"default_flags,omitempty"`
+	ExtraSeparator   string              `yaml:"extra_separator,omitempty"`   // inserted before CommandInput.Extra, such as "--" for npm run
+	ConditionalFlags []ConditionalFlag   `yaml:"conditional_flags,omitempty"` // default flags that depend on the environment
+	ExitCodes        map[int]string      `yaml:"exit_codes,omitempty"`
+	ErrorPatterns    []ErrorPattern      `yaml:"error_patterns,omitempty"` // tried in order against stderr; the first match wins
+	Before           []Command           `yaml:"before,omitempty"`         // commands to run before this one
+	Then             []Command           `yaml:"then,omitempty"`           // commands to run after this one
+	Extract          *Extract            `yaml:"extract,omitempty"`
+	Label            string              `yaml:"label,omitempty"`            // human-readable step name for chained commands
+	Optional         bool                `yaml:"optional,omitempty"`         // a failure of this chained step doesn't fail the operation
+	RequiresVersion  string              `yaml:"requires_version,omitempty"` // minimum binary version for this command
+	Unsupported      string              `yaml:"unsupported,omitempty"`      // marks the operation unsupported; the text tells the user what to do instead
+}
+
+// ErrorPackageNotFound is the error_patterns kind for output saying the
+// package isn't installed or isn't a dependency.
+const ErrorPackageNotFound = "package_not_found"
+
+// ErrorPattern maps manager output matching a regex to an error kind.
+type ErrorPattern struct {
+	Match string `yaml:"match"` // regex matched against stderr
+	Error string `yaml:"error"` // error kind, such as package_not_found
+
+	re *regexp.Regexp
+}
+
+// NewErrorPattern compiles match for use in a definition built in Go.
+func NewErrorPattern(match, kind string) (ErrorPattern, error) {
+	p := ErrorPattern{Match: match, Error: kind}
+	return p, p.compile()
+}
+
+// UnmarshalYAML compiles the pattern as the definition loads, so a bad regex
+// or unknown kind fails loading instead of never matching.
+func (p *ErrorPattern) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain ErrorPattern
+	if err := unmarshal((*plain)(p)); err != nil {
+		return err
+	}
+	return p.compile()
+}
+
+func (p *ErrorPattern) compile() error {
+	if p.Error != ErrorPackageNotFound {
+		return fmt.Errorf("error pattern %q: unknown kind %q", p.Match, p.Error)
+	}
+	re, err := regexp.Compile(p.Match)
+	if err != nil {
+		return fmt.Errorf("error pattern %q: %w", p.Match, err)
+	}
+	p.re = re
+	return nil
+}
+
+// MatchString reports whether s matches the pattern. A pattern written as a
+// struct literal rather than loaded or made with NewErrorPattern is compiled
+// on every call, and never matches if it doesn't compile.
+func (p ErrorPattern) MatchString(s string) bool {
+	if p.re != nil {
+		return p.re.MatchString(s)
+	}
+	matched, err := regexp.MatchString(p.Match, s)
+	return err == nil && matched
+}
+
+// ConditionalFlag is a default flag that is only passed when an environment
+// variable is set, such as a non-interactive flag when CI is set.
+type ConditionalFlag struct {
+	EnvVar string `yaml:"env_var"`
+	Flag   string `yaml:"flag"`
 }
 
 type Extract struct {
-	Type          string `yaml:"type"`                     // raw, json, line_prefix, regex, json_array, template
-	Field         string `yaml:"field,omitempty"`          // for json: field name to extract
-	Prefix        string `yaml:"prefix,omitempty"`         // for line_prefix: prefix to match
-	Pattern       string `yaml:"pattern,omitempty"`        // for regex: pattern with capture group; for template: path pattern with {package}
-	ArrayField    string `yaml:"array_field,omitempty"`    // for json_array: array field to search
-	MatchField    string `yaml:"match_field,omitempty"`    // for json_array: field to match against pkg name
-	ExtractField  string `yaml:"extract_field,omitempty"`  // for json_array: field to extract from matched element
-	StripFilename bool   `yaml:"strip_filename,omitempty"` // remove filename from path, returning directory
+	Type          string   `yaml:"type"`                     // raw, json, ndjson, line_prefix, regex, json_array, template, csv, tsv, go_module_cache
+	Field         string   `yaml:"field,omitempty"`          // for json/ndjson: field name to extract; dots descend into objects
+	FallbackField string   `yaml:"fallback_field,omitempty"` // for json: field tried when field is missing or empty
+	Prefix        string   `yaml:"prefix,omitempty"`         // for line_prefix: prefix to match
+	Pattern       string   `yaml:"pattern,omitempty"`        // for regex: pattern with capture group; for template: path pattern with {package}
+	ArrayField    string   `yaml:"array_field,omitempty"`    // for json_array: array field to search
+	MatchField    string   `yaml:"match_field,omitempty"`    // for json_array/ndjson: field to match against pkg name
+	ExtractField  string   `yaml:"extract_field,omitempty"`  // for json_array: field to extract from matched element
+	MatchMode     string   `yaml:"match_mode,omitempty"`     // for json_array: exact (default), contains or prefix
+	StripFilename bool     `yaml:"strip_filename,omitempty"` // remove filename from path, returning directory
+	Column        int      `yaml:"column,omitempty"`         // for csv/tsv: 0-indexed column to extract from the first data row
+	Delimiter     string   `yaml:"delimiter,omitempty"`      // for csv/tsv: column separator, defaults to "," for csv and tab for tsv
+	Fallback      *Extract `yaml:"fallback,omitempty"`       // tried on the same output when this extraction fails
 }
 
 type Arg struct {
@@ -63,24 +148,47 @@ type Arg struct {
 	Suffix         string `yaml:"suffix,omitempty"`          // append user value with this prefix, e.g. "@" for pkg@version
 	FixedSuffix    string `yaml:"fixed_suffix,omitempty"`    // always append this suffix, e.g. "@none" for go remove
 	ExtractionOnly bool   `yaml:"extraction_only,omitempty"` // arg is only used for output extraction, not passed to command
+	Default        string `yaml:"default,omitempty"`         // value used when the caller doesn't provide the arg
 }
 
 type Flag struct {
 	Values []FlagValue
+
+	// invalid holds array elements that aren't strings or {value: ...}
+	// maps. They are left out of Values and reported by ValidateDefinition.
+	invalid []any
 }
 
 type FlagValue struct {
-	Literal string
-	Field   string
-	Join    string // if set, join literal and field value with this (e.g., "=" for --flag=value)
+	Literal    string
+	Field      string
+	Join       string // if set, join literal and field value with this (e.g., "=" for --flag=value)
+	SliceField string // if set, emit Literal+item for each item of this list field (e.g., --os=linux --os=darwin)
+	EachFlag   string // with SliceField, emit this flag and the item as separate args per item (--with docs --with test)
+	Separator  string // joins a list value of Field; empty means ","
 }
 
+// UnmarshalYAML accepts either an array of strings and {value: ...} maps, or
+// a single string as shorthand for a one-element array (frozen: --frozen).
 func (f *Flag) UnmarshalYAML(unmarshal func(interface{}) error) error {
-	var raw []interface{}
-	if err := unmarshal(&raw); err != nil {
+	var node interface{}
+	if err := unmarshal(&node); err != nil {
 		return err
 	}
 
+	var raw []interface{}
+	switch val := node.(type) {
+	case nil:
+		return nil
+	case string:
+		raw = []interface{}{val}
+	case []interface{}:
+		raw = val
+	default:
+		f.invalid = append(f.invalid, val)
+		return nil
+	}
+
 	for _, v := range raw {
 		switch val := v.(type) {
 		case string:
@@ -93,9 +201,21 @@ func (f *Flag) UnmarshalYAML(unmarshal func(interface{}) error) error {
 			if join, ok := val["join"].(string); ok {
 				fv.Join = join
 			}
-			if fv.Field != "" {
+			if sep, ok := val["separator"].(string); ok {
+				fv.Separator = sep
+			}
+			if each, ok := val["each"].(string); ok {
+				fv.SliceField = each
+				fv.Literal, _ = val["prefix"].(string)
+				fv.EachFlag, _ = val["flag"].(string)
+			}
+			if fv.Field != "" || fv.SliceField != "" {
 				f.Values = append(f.Values, fv)
+			} else {
+				f.invalid = append(f.invalid, val)
 			}
+		default:
+			f.invalid = append(f.invalid, val)
 		}
 	}
 	return nil
@@ -0,0 +1,70 @@
+package definitions
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// ValidateDefinition reports mistakes in a loaded definition that the YAML
+// decoder accepts but the translator can't use, such as a flag array
+// containing true instead of a string, or a then step that repeats the
+// command it follows.
+func ValidateDefinition(def *Definition) error {
+	var errs []error
+	if def.Name == "" {
+		errs = append(errs, errors.New("definition has no name"))
+	}
+	if def.Binary == "" {
+		errs = append(errs, fmt.Errorf("%s: no binary", def.Name))
+	}
+
+	ops := make([]string, 0, len(def.Commands))
+	for op := range def.Commands {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+	for _, op := range ops {
+		errs = append(errs, validateCommand(def.Name+" "+op, def.Commands[op])...)
+	}
+
+	return errors.Join(errs...)
+}
+
+func validateCommand(label string, cmd Command) []error {
+	var errs []error
+
+	names := make([]string, 0, len(cmd.Flags))
+	for name := range cmd.Flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, v := range cmd.Flags[name].invalid {
+			errs = append(errs, fmt.Errorf("%s: flag %s: unsupported value %v (want a string or {value: field})", label, name, v))
+		}
+	}
+
+	// Loading already rejects bad patterns; this catches ones built in Go
+	for _, pattern := range cmd.ErrorPatterns {
+		if err := pattern.compile(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", label, err))
+		}
+	}
+
+	for i, before := range cmd.Before {
+		errs = append(errs, validateCommand(fmt.Sprintf("%s before[%d]", label, i), before)...)
+	}
+
+	for i, next := range cmd.Then {
+		nextLabel := fmt.Sprintf("%s then[%d]", label, i)
+		// A step that repeats its parent is almost always a copy-paste
+		// mistake, and if it carries the same then steps it never ends
+		if slices.Equal(next.Base, cmd.Base) {
+			errs = append(errs, fmt.Errorf("%s: repeats its parent's base %v", nextLabel, cmd.Base))
+		}
+		errs = append(errs, validateCommand(nextLabel, next)...)
+	}
+	return errs
+}
@@ -0,0 +1,165 @@
+package definitions
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFlagUnmarshalForms(t *testing.T) {
+	def, err := LoadFromBytes([]byte(`
+name: test
+binary: test
+commands:
+  install:
+    base: [install]
+    flags:
+      frozen: --frozen-lockfile
+      quiet: [--quiet]
+      platforms: [{each: platforms, prefix: "--os="}]
+      groups: [{each: groups, flag: --with}]
+      features: [--features, {value: features, separator: " "}]
+`))
+	if err != nil {
+		t.Fatalf("LoadFromBytes failed: %v", err)
+	}
+
+	flags := def.Commands["install"].Flags
+	if got := flags["frozen"].Values; len(got) != 1 || got[0].Literal != "--frozen-lockfile" {
+		t.Errorf("frozen: got %+v, want a single --frozen-lockfile literal", got)
+	}
+	if got := flags["quiet"].Values; len(got) != 1 || got[0].Literal != "--quiet" {
+		t.Errorf("quiet: got %+v, want a single --quiet literal", got)
+	}
+	want := FlagValue{Literal: "--os=", SliceField: "platforms"}
+	if got := flags["platforms"].Values; len(got) != 1 || got[0] != want {
+		t.Errorf("platforms: got %+v, want %+v", got, want)
+	}
+	want = FlagValue{EachFlag: "--with", SliceField: "groups"}
+	if got := flags["groups"].Values; len(got) != 1 || got[0] != want {
+		t.Errorf("groups: got %+v, want %+v", got, want)
+	}
+	want = FlagValue{Field: "features", Separator: " "}
+	if got := flags["features"].Values; len(got) != 2 || got[1] != want {
+		t.Errorf("features: got %+v, want --features then %+v", got, want)
+	}
+	if err := ValidateDefinition(def); err != nil {
+		t.Errorf("expected valid definition, got %v", err)
+	}
+}
+
+func TestValidateDefinitionRejectsBoolFlagValue(t *testing.T) {
+	def, err := LoadFromBytes([]byte(`
+name: test
+binary: test
+commands:
+  install:
+    base: [install]
+    flags:
+      frozen: [--frozen, true]
+      quiet: true
+`))
+	if err != nil {
+		t.Fatalf("LoadFromBytes failed: %v", err)
+	}
+
+	// The bad element is dropped so the flag still builds
+	if got := def.Commands["install"].Flags["frozen"].Values; len(got) != 1 {
+		t.Errorf("got %+v, want only the --frozen literal", got)
+	}
+
+	err = ValidateDefinition(def)
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	for _, want := range []string{
+		"test install: flag frozen: unsupported value true",
+		"test install: flag quiet: unsupported value true",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func TestValidateEmbeddedDefinitions(t *testing.T) {
+	defs, err := LoadEmbedded()
+	if err != nil {
+		t.Fatalf("LoadEmbedded failed: %v", err)
+	}
+	for _, def := range defs {
+		if err := ValidateDefinition(def); err != nil {
+			t.Errorf("%s: %v", def.Name, err)
+		}
+	}
+}
+
+func TestValidateDefinitionRejectsRepeatedThen(t *testing.T) {
+	def, err := LoadFromBytes([]byte(`
+name: test
+binary: test
+commands:
+  add:
+    base: [add]
+    then:
+      - base: [lock]
+        then:
+          - base: [lock]
+`))
+	if err != nil {
+		t.Fatalf("LoadFromBytes failed: %v", err)
+	}
+
+	err = ValidateDefinition(def)
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	if !strings.Contains(err.Error(), "test add then[0] then[0]: repeats its parent's base [lock]") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestLoadRejectsBadErrorPatterns(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		want    string
+	}{
+		{"invalid regex", "{match: 'not installed (', error: package_not_found}", `error pattern "not installed ("`},
+		{"unknown kind", "{match: 'no such package', error: missing}", `error pattern "no such package": unknown kind "missing"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadFromBytes([]byte(`
+name: test
+binary: test
+commands:
+  remove:
+    base: [remove]
+    error_patterns:
+      - ` + tt.pattern + `
+`))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got %v, want an error mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateDefinitionRejectsBadErrorPatterns(t *testing.T) {
+	def := &Definition{
+		Name:   "test",
+		Binary: "test",
+		Commands: map[string]Command{
+			"remove": {
+				Base:          []string{"remove"},
+				ErrorPatterns: []ErrorPattern{{Match: "not installed (", Error: ErrorPackageNotFound}},
+			},
+		},
+	}
+
+	err := ValidateDefinition(def)
+	if err == nil || !strings.Contains(err.Error(), `test remove: error pattern "not installed ("`) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
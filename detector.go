@@ -1,9 +1,15 @@
 package managers
 
 import (
+	"fmt"
+	"io/fs"
 	"os"
 	"os/exec"
+	"path"
+	"path/filepath"
 	"regexp"
+	"runtime"
+	"slices"
 	"sort"
 	"strings"
 
@@ -15,6 +21,9 @@ type DetectOptions struct {
 	OnConflict    ConflictBehavior
 	SearchParents bool
 	Manager       string
+
+	// IgnoreManagers lists managers to skip even when their files are present.
+	IgnoreManagers []string
 }
 
 type ConflictBehavior int
@@ -29,12 +38,21 @@ type Detector struct {
 	definitions []*definitions.Definition
 	translator  *Translator
 	runner      Runner
+	goos        string // compared with each definition's platform list
+
+	// FS, if set, is read instead of the OS filesystem when looking for
+	// lockfiles, manifests and project binaries, and the dir passed to
+	// Detect is a path within it (such as "." or "app"). Managers built
+	// from it still run commands in that dir, so this is mostly useful for
+	// tests with an fstest.MapFS. Nil reads the OS filesystem.
+	FS fs.FS
 }
 
 func NewDetector(translator *Translator, runner Runner) *Detector {
 	return &Detector{
 		translator: translator,
 		runner:     runner,
+		goos:       runtime.GOOS,
 	}
 }
 
@@ -45,7 +63,8 @@ func (d *Detector) Register(def *definitions.Definition) {
 }
 
 func (d *Detector) sortDefinitions() {
-	sort.Slice(d.definitions, func(i, j int) bool {
+	// Stable so managers with equal priority keep their registration order
+	sort.SliceStable(d.definitions, func(i, j int) bool {
 		return d.definitions[i].Detection.Priority > d.definitions[j].Detection.Priority
 	})
 }
@@ -55,7 +74,8 @@ func (d *Detector) Detect(dir string, opts DetectOptions) (Manager, error) {
 		return d.detectExplicit(dir, opts.Manager)
 	}
 
-	files, err := os.ReadDir(dir)
+	fsys, root := d.filesystem(dir)
+	files, err := fs.ReadDir(fsys, root)
 	if err != nil {
 		return nil, err
 	}
@@ -65,14 +85,25 @@ func (d *Detector) Detect(dir string, opts DetectOptions) (Manager, error) {
 		fileSet[f.Name()] = true
 	}
 
+	// Managers for other operating systems are never detected, so a
+	// Scoopfile checked into a cross-platform repo doesn't pick scoop on linux
+	var candidates []*definitions.Definition
+	for _, def := range d.definitions {
+		if d.supportsPlatform(def) && !slices.Contains(opts.IgnoreManagers, def.Name) {
+			candidates = append(candidates, def)
+		}
+	}
+
 	var lockfileMatches []*definitions.Definition
 	var lockfileNames []string
+	var managerNames []string
 
-	for _, def := range d.definitions {
+	for _, def := range candidates {
 		for _, lockfile := range def.Detection.Lockfiles {
 			if fileSet[lockfile] {
 				lockfileMatches = append(lockfileMatches, def)
 				lockfileNames = append(lockfileNames, lockfile)
+				managerNames = append(managerNames, def.Name)
 			}
 		}
 	}
@@ -81,6 +112,7 @@ func (d *Detector) Detect(dir string, opts DetectOptions) (Manager, error) {
 		return nil, ErrConflictingLockfiles{
 			Dir:       dir,
 			Lockfiles: lockfileNames,
+			Managers:  managerNames,
 		}
 	}
 
@@ -89,7 +121,25 @@ func (d *Detector) Detect(dir string, opts DetectOptions) (Manager, error) {
 		return d.buildManager(def, dir, lockfileNames[:1], opts.RequireCLI)
 	}
 
-	for _, def := range d.definitions {
+	// Prefer managers whose file checks confirm the manifest is theirs
+	// (e.g. [tool.poetry] in pyproject.toml), then fall back to any manager
+	// that recognises the manifest.
+	for _, def := range candidates {
+		for _, manifest := range def.Detection.Manifests {
+			if !fileSet[manifest] {
+				continue
+			}
+			ok, err := checkFileMatches(fsys, root, manifest, def.Detection.FileChecks)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				return d.buildManager(def, dir, []string{manifest}, opts.RequireCLI)
+			}
+		}
+	}
+
+	for _, def := range candidates {
 		for _, manifest := range def.Detection.Manifests {
 			if fileSet[manifest] {
 				return d.buildManager(def, dir, []string{manifest}, opts.RequireCLI)
@@ -100,32 +150,103 @@ func (d *Detector) Detect(dir string, opts DetectOptions) (Manager, error) {
 	return nil, ErrNoManifest{Dir: dir}
 }
 
+// filesystem returns the filesystem detection reads and the path of dir
+// within it.
+func (d *Detector) filesystem(dir string) (fs.FS, string) {
+	if d.FS != nil {
+		return d.FS, dir
+	}
+	return os.DirFS(dir), "."
+}
+
+// checkFileMatches reports whether every file check on manifest with a
+// Match pattern finds the pattern in the file. Checks on other files don't
+// apply, so pixi's check on pyproject.toml doesn't stop pixi.toml matching.
+// A missing file fails the check.
+func checkFileMatches(fsys fs.FS, dir, manifest string, checks []definitions.FileCheck) (bool, error) {
+	for _, check := range checks {
+		if check.Match == "" || check.File != manifest {
+			continue
+		}
+
+		re, err := regexp.Compile(check.Match)
+		if err != nil {
+			return false, fmt.Errorf("invalid file check pattern for %s: %w", check.File, err)
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(dir, check.File))
+		if err != nil {
+			return false, nil
+		}
+
+		if !re.Match(content) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
 func (d *Detector) detectExplicit(dir, managerName string) (Manager, error) {
 	for _, def := range d.definitions {
 		if def.Name == managerName {
+			if !d.supportsPlatform(def) {
+				return nil, ErrUnsupportedPlatform{
+					Manager:   def.Name,
+					Platform:  d.goos,
+					Platforms: def.Platform,
+				}
+			}
 			return d.buildManager(def, dir, nil, true)
 		}
 	}
 	return nil, ErrNoManifest{Dir: dir}
 }
 
+// supportsPlatform reports whether def runs on the detector's operating
+// system. A definition without a platform list runs anywhere.
+func (d *Detector) supportsPlatform(def *definitions.Definition) bool {
+	return len(def.Platform) == 0 || slices.Contains(def.Platform, d.goos)
+}
+
+// projectBinary returns the first of def's binary alternatives that exists in
+// dir, such as a committed ./gradlew wrapper, or "" if there are none.
+func projectBinary(fsys fs.FS, dir string, def *definitions.Definition) string {
+	for _, bin := range def.BinaryAlternatives {
+		info, err := fs.Stat(fsys, path.Join(dir, bin))
+		if err == nil && !info.IsDir() {
+			return bin
+		}
+	}
+	return ""
+}
+
 func (d *Detector) buildManager(def *definitions.Definition, dir string, files []string, requireCLI bool) (Manager, error) {
-	if requireCLI {
+	fsys, root := d.filesystem(dir)
+	binary := projectBinary(fsys, root, def)
+	if requireCLI && binary == "" {
 		if _, err := exec.LookPath(def.Binary); err != nil {
 			return nil, ErrCLINotFound{
 				Manager: def.Name,
 				Binary:  def.Binary,
 				Files:   files,
+				Website: def.Website,
 			}
 		}
 	}
 
-	return &GenericManager{
+	mgr := &GenericManager{
 		def:        def,
 		dir:        dir,
 		translator: d.translator,
-		runner:     d.runner,
-	}, nil
+		runner:     NewExitCodeAwareRunner(d.runner, def),
+		binary:     binary,
+
+		detectVersion: d.DetectVersion,
+	}
+	if len(files) > 0 {
+		mgr.manifestFile = filepath.Join(dir, files[0])
+	}
+	return mgr, nil
 }
 
 func (d *Detector) DetectVersion(def *definitions.Definition) (string, error) {
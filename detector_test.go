@@ -0,0 +1,305 @@
+package managers
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/git-pkgs/managers/definitions"
+)
+
+func loadDetector(t *testing.T, fsys fs.FS) *Detector {
+	t.Helper()
+	defs, err := definitions.LoadEmbedded()
+	if err != nil {
+		t.Fatalf("failed to load definitions: %v", err)
+	}
+
+	detector := NewDetector(NewTranslator(), NewMockRunner())
+	detector.FS = fsys
+	for _, def := range defs {
+		detector.Register(def)
+	}
+	return detector
+}
+
+// mapFS builds an in-memory project directory for Detector.FS.
+func mapFS(files map[string]string) fstest.MapFS {
+	fsys := fstest.MapFS{}
+	for name, content := range files {
+		fsys[name] = &fstest.MapFile{Data: []byte(content)}
+	}
+	return fsys
+}
+
+func TestDetectFileCheckMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"poetry section", "[tool.poetry]\nname = \"app\"\n", "poetry"},
+		{"uv section", "[project]\nname = \"app\"\n\n[tool.uv]\ndev-dependencies = []\n", "uv"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := mapFS(map[string]string{"pyproject.toml": tt.content})
+
+			mgr, err := loadDetector(t, fsys).Detect(".", DetectOptions{})
+			if err != nil {
+				t.Fatalf("Detect failed: %v", err)
+			}
+			if mgr.Name() != tt.want {
+				t.Errorf("got %q, want %q", mgr.Name(), tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectFileCheckFallback(t *testing.T) {
+	fsys := mapFS(map[string]string{"pyproject.toml": "[project]\nname = \"app\"\n"})
+
+	mgr, err := loadDetector(t, fsys).Detect(".", DetectOptions{})
+	if err != nil {
+		t.Fatalf("Detect failed: %v", err)
+	}
+	if mgr.Ecosystem() != "pypi" {
+		t.Errorf("got ecosystem %q, want pypi", mgr.Ecosystem())
+	}
+}
+
+func TestDetectLockfileIgnoresFileChecks(t *testing.T) {
+	fsys := mapFS(map[string]string{
+		"pyproject.toml": "[project]\nname = \"app\"\n",
+		"poetry.lock":    "",
+	})
+
+	mgr, err := loadDetector(t, fsys).Detect(".", DetectOptions{})
+	if err != nil {
+		t.Fatalf("Detect failed: %v", err)
+	}
+	if mgr.Name() != "poetry" {
+		t.Errorf("got %q, want poetry", mgr.Name())
+	}
+}
+
+func TestDetectorIgnoreManager(t *testing.T) {
+	fsys := mapFS(map[string]string{
+		"Gemfile.lock": "",
+		"uv.lock":      "",
+	})
+
+	mgr, err := loadDetector(t, fsys).Detect(".", DetectOptions{IgnoreManagers: []string{"bundler"}})
+	if err != nil {
+		t.Fatalf("Detect failed: %v", err)
+	}
+	if mgr.Name() != "uv" {
+		t.Errorf("got %q, want %q", mgr.Name(), "uv")
+	}
+}
+
+func TestDetectConflictingLockfilesNamesManagers(t *testing.T) {
+	fsys := mapFS(map[string]string{
+		"pnpm-lock.yaml":    "",
+		"package-lock.json": "",
+	})
+
+	_, err := loadDetector(t, fsys).Detect(".", DetectOptions{})
+	var conflict ErrConflictingLockfiles
+	if !errors.As(err, &conflict) {
+		t.Fatalf("expected ErrConflictingLockfiles, got %v", err)
+	}
+
+	owners := map[string]string{}
+	for i, name := range conflict.Managers {
+		owners[conflict.Lockfiles[i]] = name
+	}
+	if owners["pnpm-lock.yaml"] != "pnpm" || owners["package-lock.json"] != "npm" {
+		t.Errorf("unexpected lockfile owners: %v", owners)
+	}
+
+	msg := err.Error()
+	for _, want := range []string{"both ", "pnpm (pnpm-lock.yaml)", "npm (package-lock.json)", "detected in ."} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("error %q does not mention %q", msg, want)
+		}
+	}
+}
+
+func TestDetectPixi(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{"lockfile", map[string]string{"pixi.lock": "", "pixi.toml": ""}, "pixi"},
+		{"pixi.toml", map[string]string{"pixi.toml": "[project]\n"}, "pixi"},
+		{"pyproject section", map[string]string{"pyproject.toml": "[project]\nname = \"app\"\n\n[tool.pixi.project]\nchannels = []\n"}, "pixi"},
+		{"poetry pyproject", map[string]string{"pyproject.toml": "[tool.poetry]\nname = \"app\"\n"}, "poetry"},
+		{"pixi.toml beside plain pyproject", map[string]string{"pixi.toml": "[project]\n", "pyproject.toml": "[project]\nname = \"app\"\n"}, "pixi"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := mapFS(tt.files)
+
+			mgr, err := loadDetector(t, fsys).Detect(".", DetectOptions{})
+			if err != nil {
+				t.Fatalf("Detect failed: %v", err)
+			}
+			if mgr.Name() != tt.want {
+				t.Errorf("got %q, want %q", mgr.Name(), tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectSkipsOtherPlatforms(t *testing.T) {
+	fsys := mapFS(map[string]string{"Scoopfile": "{}"})
+
+	detector := loadDetector(t, fsys)
+	detector.goos = "linux"
+	if _, err := detector.Detect(".", DetectOptions{}); !errors.As(err, new(ErrNoManifest)) {
+		t.Errorf("got %v, want ErrNoManifest on linux", err)
+	}
+
+	detector.goos = "windows"
+	mgr, err := detector.Detect(".", DetectOptions{})
+	if err != nil {
+		t.Fatalf("Detect failed: %v", err)
+	}
+	if mgr.Name() != "scoop" {
+		t.Errorf("got %q, want scoop on windows", mgr.Name())
+	}
+}
+
+func TestDetectExplicitUnsupportedPlatform(t *testing.T) {
+	detector := loadDetector(t, mapFS(nil))
+	detector.goos = "windows"
+
+	_, err := detector.Detect(".", DetectOptions{Manager: "apt"})
+	var perr ErrUnsupportedPlatform
+	if !errors.As(err, &perr) {
+		t.Fatalf("got %v, want ErrUnsupportedPlatform", err)
+	}
+	if perr.Manager != "apt" || perr.Platform != "windows" {
+		t.Errorf("got %+v, want apt on windows", perr)
+	}
+}
+
+func TestDetectedManagerOutdatedExitCode(t *testing.T) {
+	fsys := mapFS(map[string]string{
+		"package.json":      "{}",
+		"package-lock.json": "{}",
+	})
+
+	// Like ExecRunner, report the exit status only in the result
+	runner := NewMockRunner()
+	runner.OnArgs([]string{"npm", "outdated", "--json"}, &Result{ExitCode: 1, Stdout: `{"lodash": {}}`}, nil)
+	runner.OnArgs([]string{"npm", "install"}, &Result{ExitCode: 1}, nil)
+
+	defs, err := definitions.LoadEmbedded()
+	if err != nil {
+		t.Fatalf("failed to load definitions: %v", err)
+	}
+	detector := NewDetector(NewTranslator(), runner)
+	detector.FS = fsys
+	for _, def := range defs {
+		detector.Register(def)
+	}
+
+	mgr, err := detector.Detect(".", DetectOptions{})
+	if err != nil {
+		t.Fatalf("Detect failed: %v", err)
+	}
+
+	result, err := mgr.Outdated(context.Background())
+	if err != nil {
+		t.Fatalf("expected outdated exit code to be accepted, got %v", err)
+	}
+	if result.ExitCode != 1 {
+		t.Errorf("expected exit code 1, got %d", result.ExitCode)
+	}
+
+	_, err = mgr.Install(context.Background(), InstallOptions{})
+	want := ErrCommandFailed{Manager: "npm", Operation: "install", ExitCode: 1}
+	if !errors.Is(err, want) {
+		t.Errorf("got %v, want %v", err, want)
+	}
+}
+
+func TestDetectPrefersProjectBinary(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		expected []string
+	}{
+		{"wrapper", map[string]string{"build.gradle": "", "gradlew": "#!/bin/sh\n"}, []string{"./gradlew", "dependencies", "--write-locks"}},
+		{"no wrapper", map[string]string{"build.gradle": ""}, []string{"gradle", "dependencies", "--write-locks"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := mapFS(tt.files)
+
+			runner := NewMockRunner()
+			detector := NewDetector(NewTranslator(), runner)
+			detector.FS = fsys
+			detector.Register(embeddedDefinition(t, "gradle"))
+
+			mgr, err := detector.Detect(".", DetectOptions{})
+			if err != nil {
+				t.Fatalf("Detect failed: %v", err)
+			}
+			if _, err := mgr.Install(context.Background(), InstallOptions{}); err != nil {
+				t.Fatalf("Install failed: %v", err)
+			}
+			if !slicesEqual(runner.LastCaptured(), tt.expected) {
+				t.Errorf("got command %v, want %v", runner.LastCaptured(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestDetectReadsOSFilesystemByDefault(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{"go.mod": "module example.com/app\n", "go.sum": ""} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mgr, err := loadDetector(t, nil).Detect(dir, DetectOptions{})
+	if err != nil {
+		t.Fatalf("Detect failed: %v", err)
+	}
+	if mgr.Name() != "gomod" {
+		t.Errorf("got %q, want gomod", mgr.Name())
+	}
+}
+
+func TestDetectCLINotFoundLinksWebsite(t *testing.T) {
+	detector := NewDetector(NewTranslator(), NewMockRunner())
+	detector.FS = mapFS(map[string]string{"tool.lock": ""})
+	detector.Register(&definitions.Definition{
+		Name:      "tool",
+		Binary:    "git-pkgs-missing-tool",
+		Website:   "https://tool.example.com/docs",
+		Detection: definitions.Detection{Lockfiles: []string{"tool.lock"}},
+	})
+
+	_, err := detector.Detect(".", DetectOptions{RequireCLI: true})
+	var notFound ErrCLINotFound
+	if !errors.As(err, &notFound) {
+		t.Fatalf("expected ErrCLINotFound, got %v", err)
+	}
+	if !strings.HasSuffix(err.Error(), ", see https://tool.example.com/docs") {
+		t.Errorf("expected error to link the website, got %q", err)
+	}
+}
@@ -0,0 +1,59 @@
+package managers
+
+// Diff builds the command for operation from two inputs and returns the
+// tokens that differ, in command order: "-tok" for a token only in the first
+// command and "+tok" for a token only in the second. Identical commands give
+// an empty diff.
+func (t *Translator) Diff(managerName, operation string, before, after CommandInput) ([]string, error) {
+	a, err := t.BuildCommand(managerName, operation, before)
+	if err != nil {
+		return nil, err
+	}
+	b, err := t.BuildCommand(managerName, operation, after)
+	if err != nil {
+		return nil, err
+	}
+	return diffTokens(a, b), nil
+}
+
+// diffTokens returns the edit script between a and b using their longest
+// common subsequence. Commands are short, so the quadratic table is fine.
+func diffTokens(a, b []string) []string {
+	// lcs[i][j] is the length of the common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var diff []string
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, "-"+a[i])
+			i++
+		default:
+			diff = append(diff, "+"+b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		diff = append(diff, "-"+a[i])
+	}
+	for ; j < len(b); j++ {
+		diff = append(diff, "+"+b[j])
+	}
+	return diff
+}
@@ -0,0 +1,81 @@
+package managers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffTokens(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     []string
+		expected []string
+	}{
+		{"identical", []string{"npm", "install"}, []string{"npm", "install"}, nil},
+		{"added", []string{"npm", "install"}, []string{"npm", "install", "--ignore-scripts"}, []string{"+--ignore-scripts"}},
+		{"removed", []string{"npm", "install", "lodash", "--save-dev"}, []string{"npm", "install", "lodash"}, []string{"---save-dev"}},
+		{"replaced", []string{"npm", "install"}, []string{"npm", "ci"}, []string{"-install", "+ci"}},
+		{"empty", nil, []string{"npm"}, []string{"+npm"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := diffTokens(tt.a, tt.b)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("got %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTranslatorDiff(t *testing.T) {
+	tr := loadTranslator(t)
+	before := CommandInput{
+		Args: map[string]string{"package": "lodash"},
+	}
+	after := CommandInput{
+		Args:  map[string]string{"package": "lodash"},
+		Flags: map[string]any{"dev": true},
+	}
+
+	diff, err := tr.Diff("npm", "add", before, after)
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	expected := []string{"+--save-dev"}
+	if !reflect.DeepEqual(diff, expected) {
+		t.Errorf("got %v, want %v", diff, expected)
+	}
+
+	if _, err := tr.Diff("npm", "add", CommandInput{}, after); err == nil {
+		t.Error("expected error when a command can't be built")
+	}
+}
+
+func TestTranslatorDiffSameInputWithSeveralFlags(t *testing.T) {
+	tr := loadTranslator(t)
+	input := CommandInput{
+		Args:  map[string]string{"package": "lodash"},
+		Flags: map[string]any{"dev": true, "exact": true},
+	}
+
+	// Flags come from a map, so repeat enough times to catch random ordering
+	for range 50 {
+		diff, err := tr.Diff("npm", "add", input, input)
+		if err != nil {
+			t.Fatalf("Diff failed: %v", err)
+		}
+		if len(diff) != 0 {
+			t.Fatalf("expected no diff for identical input, got %v", diff)
+		}
+	}
+
+	cmd, err := tr.BuildCommand("npm", "add", input)
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"npm", "install", "lodash", "--save-dev", "--save-exact"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
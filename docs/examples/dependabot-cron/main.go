@@ -20,6 +20,8 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
@@ -50,17 +52,22 @@ func run(ctx context.Context, repoPath string) error {
 	}
 
 	translator := managers.NewTranslator()
+	detector := managers.NewDetector(translator, managers.NewExecRunner())
 	for _, def := range defs {
-		translator.Register(def)
+		detector.Register(def)
 	}
 
 	// Detect package manager
-	managerName, err := detectManager(repoPath)
+	mgr, err := detector.Detect(repoPath, managers.DetectOptions{})
 	if err != nil {
 		return fmt.Errorf("detecting manager: %w", err)
 	}
+	managerName := mgr.Name()
 	fmt.Printf("Detected package manager: %s\n", managerName)
 
+	// Only commit the files the manager owns
+	files := dependencyFiles(mgr.(*managers.GenericManager).Definition(), repoPath)
+
 	// Get outdated dependencies
 	outdated, err := getOutdated(ctx, translator, managerName, repoPath)
 	if err != nil {
@@ -76,7 +83,7 @@ func run(ctx context.Context, repoPath string) error {
 
 	// Update each dependency
 	for _, dep := range outdated {
-		if err := updateDependency(ctx, translator, managerName, repoPath, dep); err != nil {
+		if err := updateDependency(ctx, translator, managerName, repoPath, files, dep); err != nil {
 			fmt.Printf("Warning: failed to update %s: %v\n", dep.Name, err)
 			continue
 		}
@@ -92,30 +99,16 @@ type Dependency struct {
 	Latest  string
 }
 
-// detectManager finds the package manager for a repository
-// In a real implementation, this would use the detector from the managers library
-func detectManager(repoPath string) (string, error) {
-	checks := []struct {
-		file    string
-		manager string
-	}{
-		{"pnpm-lock.yaml", "pnpm"},
-		{"yarn.lock", "yarn"},
-		{"package-lock.json", "npm"},
-		{"Gemfile.lock", "bundler"},
-		{"Cargo.lock", "cargo"},
-		{"go.sum", "gomod"},
-		{"uv.lock", "uv"},
-	}
-
-	for _, check := range checks {
-		path := repoPath + "/" + check.file
-		if _, err := os.Stat(path); err == nil {
-			return check.manager, nil
+// dependencyFiles returns the lockfiles and manifests from the manager's
+// definition that exist in the repository
+func dependencyFiles(def *definitions.Definition, repoPath string) []string {
+	var files []string
+	for _, name := range slices.Concat(def.Detection.Lockfiles, def.Detection.Manifests) {
+		if _, err := os.Stat(filepath.Join(repoPath, name)); err == nil {
+			files = append(files, name)
 		}
 	}
-
-	return "", fmt.Errorf("no supported package manager found")
+	return files
 }
 
 // getOutdated returns a list of outdated dependencies
@@ -215,7 +208,7 @@ func parseOutdated(managerName string, output []byte) ([]Dependency, error) {
 }
 
 // updateDependency updates a single dependency in its own branch
-func updateDependency(ctx context.Context, tr *managers.Translator, managerName, repoPath string, dep Dependency) error {
+func updateDependency(ctx context.Context, tr *managers.Translator, managerName, repoPath string, files []string, dep Dependency) error {
 	branchName := fmt.Sprintf("deps/%s-%s", dep.Name, dep.Latest)
 	fmt.Printf("Updating %s to %s (branch: %s)\n", dep.Name, dep.Latest, branchName)
 
@@ -238,7 +231,7 @@ func updateDependency(ctx context.Context, tr *managers.Translator, managerName,
 	}
 
 	// Commit the changes
-	if err := gitCommand(repoPath, "add", "."); err != nil {
+	if err := gitCommand(repoPath, append([]string{"add", "--"}, files...)...); err != nil {
 		return err
 	}
 
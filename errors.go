@@ -25,34 +25,77 @@ type ErrCLINotFound struct {
 	Manager string
 	Binary  string
 	Files   []string
+	Website string // the manager's documentation, if its definition has one
 }
 
 func (e ErrCLINotFound) Error() string {
-	return fmt.Sprintf("%s not found (detected from %s). Install %s or add it to PATH",
+	msg := fmt.Sprintf("%s not found (detected from %s). Install %s or add it to PATH",
 		e.Binary, strings.Join(e.Files, ", "), e.Manager)
+	if e.Website != "" {
+		msg += ", see " + e.Website
+	}
+	return msg
 }
 
-type ErrUnsupportedVersion struct {
+// ErrPackageNotFound is returned when the manager's output matches one of
+// the command's error_patterns for a package that isn't installed.
+type ErrPackageNotFound struct {
+	Package string
 	Manager string
-	Version string
-	Nearest string
+}
+
+func (e ErrPackageNotFound) Error() string {
+	return fmt.Sprintf("%s: package %s not found", e.Manager, e.Package)
+}
+
+type ErrUnsupportedVersion struct {
+	Manager  string
+	Version  string
+	Nearest  string
+	Required string // minimum version an operation needs (requires_version)
 }
 
 func (e ErrUnsupportedVersion) Error() string {
+	if e.Required != "" {
+		return fmt.Sprintf("%s %s not supported (requires >= %s)", e.Manager, e.Version, e.Required)
+	}
 	if e.Nearest != "" {
 		return fmt.Sprintf("%s %s not supported (nearest: %s)", e.Manager, e.Version, e.Nearest)
 	}
 	return fmt.Sprintf("%s %s not supported", e.Manager, e.Version)
 }
 
+type ErrUnsupportedPlatform struct {
+	Manager   string
+	Platform  string
+	Platforms []string
+}
+
+func (e ErrUnsupportedPlatform) Error() string {
+	return fmt.Sprintf("%s is not available on %s (supported: %s)", e.Manager, e.Platform, strings.Join(e.Platforms, ", "))
+}
+
 type ErrConflictingLockfiles struct {
 	Dir       string
 	Lockfiles []string
+	Managers  []string // Managers[i] is the manager that owns Lockfiles[i]
 }
 
 func (e ErrConflictingLockfiles) Error() string {
-	return fmt.Sprintf("multiple lockfiles in %s: %s. Remove all but one or specify manager explicitly",
-		e.Dir, strings.Join(e.Lockfiles, ", "))
+	if len(e.Managers) != len(e.Lockfiles) || len(e.Managers) < 2 {
+		return fmt.Sprintf("multiple lockfiles in %s: %s. Remove all but one or specify manager explicitly",
+			e.Dir, strings.Join(e.Lockfiles, ", "))
+	}
+
+	found := make([]string, len(e.Managers))
+	for i, name := range e.Managers {
+		found[i] = fmt.Sprintf("%s (%s)", name, e.Lockfiles[i])
+	}
+	list := strings.Join(found[:len(found)-1], ", ") + " and " + found[len(found)-1]
+	if len(found) == 2 {
+		list = "both " + list
+	}
+	return fmt.Sprintf("%s detected in %s. Remove all but one lockfile or specify manager explicitly", list, e.Dir)
 }
 
 type ErrManifestNotInRoot struct {
@@ -114,3 +157,28 @@ func (e ErrPolicyCheck) Error() string {
 func (e ErrPolicyCheck) Unwrap() error {
 	return e.Err
 }
+
+// ErrCommandFailed is returned by ExitCodeAwareRunner when a command exits
+// with a code its definition doesn't describe as a normal result.
+type ErrCommandFailed struct {
+	Manager   string
+	Operation string
+	ExitCode  int
+}
+
+func (e ErrCommandFailed) Error() string {
+	return fmt.Sprintf("%s %s exited with code %d", e.Manager, e.Operation, e.ExitCode)
+}
+
+// ErrCircularCommandChain is returned when an operation's "then" steps nest
+// deeper than the translator allows, which usually means the chain loops.
+type ErrCircularCommandChain struct {
+	Manager   string
+	Operation string
+	Depth     int
+}
+
+func (e ErrCircularCommandChain) Error() string {
+	return fmt.Sprintf("%s %s: command chain nested %d levels deep, check its then steps for a loop",
+		e.Manager, e.Operation, e.Depth)
+}
@@ -3,6 +3,7 @@ package managers
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"path/filepath"
 	"regexp"
 	"strings"
@@ -15,20 +16,36 @@ func ExtractPath(output string, extract *definitions.Extract, pkg string) (strin
 		return strings.TrimSpace(output), nil
 	}
 
+	result, err := extractOnce(output, extract, pkg)
+	if err != nil && extract.Fallback != nil {
+		return ExtractPath(output, extract.Fallback, pkg)
+	}
+	return result, err
+}
+
+func extractOnce(output string, extract *definitions.Extract, pkg string) (string, error) {
 	var result string
 	var err error
 
 	switch extract.Type {
 	case "json":
-		result, err = extractJSON(output, extract.Field)
+		result, err = extractJSON(output, extract.Field, extract.FallbackField)
+	case "ndjson":
+		result, err = extractNDJSON(output, extract.Field, extract.MatchField, pkg)
 	case "line_prefix":
 		result, err = extractLinePrefix(output, extract.Prefix)
 	case "regex":
 		result, err = extractRegex(output, extract.Pattern)
 	case "json_array":
-		result, err = extractJSONArray(output, extract.ArrayField, extract.MatchField, extract.ExtractField, pkg)
+		result, err = extractJSONArray(output, extract.ArrayField, extract.MatchField, extract.MatchMode, extract.ExtractField, pkg)
 	case "template":
 		result, err = extractTemplate(extract.Pattern, pkg)
+	case "csv":
+		result, err = extractColumn(output, extract.Delimiter, ",", extract.Column)
+	case "tsv":
+		result, err = extractColumn(output, extract.Delimiter, "\t", extract.Column)
+	case "go_module_cache":
+		result, err = extractGoModuleCache(output)
 	default:
 		return "", fmt.Errorf("unknown extract type: %s", extract.Type)
 	}
@@ -44,7 +61,7 @@ func ExtractPath(output string, extract *definitions.Extract, pkg string) (strin
 	return result, nil
 }
 
-func extractJSON(output string, field string) (string, error) {
+func extractJSON(output, field, fallbackField string) (string, error) {
 	if field == "" {
 		return "", fmt.Errorf("json extraction requires field name")
 	}
@@ -54,9 +71,59 @@ func extractJSON(output string, field string) (string, error) {
 		return "", fmt.Errorf("failed to parse JSON: %w", err)
 	}
 
-	value, ok := data[field]
-	if !ok {
-		return "", fmt.Errorf("field %q not found in JSON", field)
+	result, err := stringField(data, field)
+	if (err != nil || result == "") && fallbackField != "" {
+		return stringField(data, fallbackField)
+	}
+	return result, err
+}
+
+// extractNDJSON reads a stream of JSON objects, such as `go list -m -json all`.
+// With matchField set it uses the first object whose matchField equals pkg,
+// otherwise the first object in the stream.
+func extractNDJSON(output, field, matchField, pkg string) (string, error) {
+	if field == "" {
+		return "", fmt.Errorf("ndjson extraction requires field name")
+	}
+
+	dec := json.NewDecoder(strings.NewReader(output))
+	for {
+		var data map[string]any
+		if err := dec.Decode(&data); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return "", fmt.Errorf("failed to parse JSON: %w", err)
+		}
+
+		if matchField != "" {
+			if name, ok := data[matchField].(string); !ok || name != pkg {
+				continue
+			}
+		}
+
+		return stringField(data, field)
+	}
+
+	if matchField != "" {
+		return "", fmt.Errorf("no object found with %s=%q", matchField, pkg)
+	}
+	return "", fmt.Errorf("no JSON object found")
+}
+
+// stringField returns a string field from decoded JSON. Dotted names such as
+// "Replace.Dir" descend into nested objects.
+func stringField(data map[string]any, field string) (string, error) {
+	var value any = data
+	for _, key := range strings.Split(field, ".") {
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return "", fmt.Errorf("field %q not found in JSON", field)
+		}
+		value, ok = obj[key]
+		if !ok {
+			return "", fmt.Errorf("field %q not found in JSON", field)
+		}
 	}
 
 	str, ok := value.(string)
@@ -110,11 +177,49 @@ func extractTemplate(pattern, pkg string) (string, error) {
 	return strings.ReplaceAll(pattern, "{package}", pkg), nil
 }
 
-func extractJSONArray(output, arrayField, matchField, extractField, pkg string) (string, error) {
+// extractColumn returns a column from the first data row of a delimited table,
+// skipping blank lines and comment lines starting with "#".
+func extractColumn(output, delimiter, defaultDelimiter string, column int) (string, error) {
+	if delimiter == "" {
+		delimiter = defaultDelimiter
+	}
+	if column < 0 {
+		return "", fmt.Errorf("column must not be negative")
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		cells := strings.Split(strings.TrimRight(line, "\r"), delimiter)
+		if column >= len(cells) {
+			return "", fmt.Errorf("column %d not found in row with %d columns", column, len(cells))
+		}
+		return strings.TrimSpace(cells[column]), nil
+	}
+
+	return "", fmt.Errorf("no data rows found")
+}
+
+func extractJSONArray(output, arrayField, matchField, matchMode, extractField, pkg string) (string, error) {
 	if arrayField == "" || matchField == "" || extractField == "" {
 		return "", fmt.Errorf("json_array extraction requires array_field, match_field, and extract_field")
 	}
 
+	var match func(name string) bool
+	switch matchMode {
+	case "", "exact":
+		match = func(name string) bool { return name == pkg }
+	case "contains":
+		match = func(name string) bool { return strings.Contains(name, pkg) }
+	case "prefix":
+		match = func(name string) bool { return strings.HasPrefix(name, pkg) }
+	default:
+		return "", fmt.Errorf("unknown match_mode: %s", matchMode)
+	}
+
 	var data map[string]any
 	if err := json.Unmarshal([]byte(output), &data); err != nil {
 		return "", fmt.Errorf("failed to parse JSON: %w", err)
@@ -132,7 +237,7 @@ func extractJSONArray(output, arrayField, matchField, extractField, pkg string)
 		}
 
 		name, ok := obj[matchField].(string)
-		if !ok || name != pkg {
+		if !ok || !match(name) {
 			continue
 		}
 
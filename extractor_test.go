@@ -1,6 +1,7 @@
 package managers
 
 import (
+	"path/filepath"
 	"testing"
 
 	"github.com/git-pkgs/managers/definitions"
@@ -54,6 +55,139 @@ func TestExtractPath_JSON_MissingField(t *testing.T) {
 	}
 }
 
+func TestExtractPath_JSON_RejectsStream(t *testing.T) {
+	// Streams of objects need the ndjson type
+	output := `{"Path": "example.com/a", "Dir": "/mod/a"}
+{"Path": "example.com/b", "Dir": "/mod/b"}`
+	_, err := ExtractPath(output, &definitions.Extract{
+		Type:  "json",
+		Field: "Dir",
+	}, "")
+	if err == nil {
+		t.Error("expected error for trailing data after the JSON object")
+	}
+}
+
+func TestExtractPath_JSONWithFallback(t *testing.T) {
+	output := `{"Path": "example.com/foo", "Version": "v1.0.0", "Replace": {"Path": "../foo", "Dir": "/home/user/src/foo"}}`
+	result, err := ExtractPath(output, &definitions.Extract{
+		Type:          "json",
+		Field:         "Dir",
+		FallbackField: "Replace.Dir",
+	}, "")
+	if err != nil {
+		t.Fatalf("ExtractPath failed: %v", err)
+	}
+	expected := "/home/user/src/foo"
+	if result != expected {
+		t.Errorf("got %q, want %q", result, expected)
+	}
+}
+
+func TestExtractPath_JSONFallbackNotUsedOnSuccess(t *testing.T) {
+	output := `{"Dir": "/primary", "Replace": {"Dir": "/replacement"}}`
+	result, err := ExtractPath(output, &definitions.Extract{
+		Type:          "json",
+		Field:         "Dir",
+		FallbackField: "Replace.Dir",
+	}, "")
+	if err != nil {
+		t.Fatalf("ExtractPath failed: %v", err)
+	}
+	if result != "/primary" {
+		t.Errorf("got %q, want %q", result, "/primary")
+	}
+}
+
+func TestExtractPath_NDJSON(t *testing.T) {
+	output := `{"Path": "example.com/main", "Main": true}
+{
+	"Path": "github.com/pkg/errors",
+	"Version": "v0.9.1",
+	"Dir": "/home/user/go/pkg/mod/github.com/pkg/errors@v0.9.1"
+}
+{"Path": "golang.org/x/sync", "Dir": "/home/user/go/pkg/mod/golang.org/x/sync@v0.7.0"}`
+	result, err := ExtractPath(output, &definitions.Extract{
+		Type:       "ndjson",
+		Field:      "Dir",
+		MatchField: "Path",
+	}, "github.com/pkg/errors")
+	if err != nil {
+		t.Fatalf("ExtractPath failed: %v", err)
+	}
+	expected := "/home/user/go/pkg/mod/github.com/pkg/errors@v0.9.1"
+	if result != expected {
+		t.Errorf("got %q, want %q", result, expected)
+	}
+}
+
+func TestExtractPath_NDJSON_FirstObject(t *testing.T) {
+	output := `{"Path": "example.com/a", "Version": "v1.0.0"}
+{"Path": "example.com/b", "Version": "v2.0.0"}`
+	result, err := ExtractPath(output, &definitions.Extract{
+		Type:  "ndjson",
+		Field: "Version",
+	}, "")
+	if err != nil {
+		t.Fatalf("ExtractPath failed: %v", err)
+	}
+	if result != "v1.0.0" {
+		t.Errorf("got %q, want %q", result, "v1.0.0")
+	}
+}
+
+func TestExtractPath_NDJSON_NotFound(t *testing.T) {
+	output := `{"Path": "example.com/a", "Dir": "/mod/a"}`
+	_, err := ExtractPath(output, &definitions.Extract{
+		Type:       "ndjson",
+		Field:      "Dir",
+		MatchField: "Path",
+	}, "example.com/missing")
+	if err == nil {
+		t.Error("expected error for missing object, got nil")
+	}
+}
+
+func TestExtractPath_Fallback(t *testing.T) {
+	t.Setenv("GOMODCACHE", "/home/user/go/pkg/mod")
+	output := `{"Path": "github.com/BurntSushi/toml", "Version": "v1.3.2"}`
+	result, err := ExtractPath(output, &definitions.Extract{
+		Type:     "json",
+		Field:    "Dir",
+		Fallback: &definitions.Extract{Type: "go_module_cache"},
+	}, "github.com/BurntSushi/toml")
+	if err != nil {
+		t.Fatalf("ExtractPath failed: %v", err)
+	}
+	expected := filepath.Join("/home/user/go/pkg/mod", "github.com", "!burnt!sushi", "toml@v1.3.2")
+	if result != expected {
+		t.Errorf("got %q, want %q", result, expected)
+	}
+}
+
+func TestExtractPath_FallbackNotUsedOnSuccess(t *testing.T) {
+	output := `{"Path": "example.com/a", "Version": "v1.0.0", "Dir": "/mod/a"}`
+	result, err := ExtractPath(output, &definitions.Extract{
+		Type:     "json",
+		Field:    "Dir",
+		Fallback: &definitions.Extract{Type: "go_module_cache"},
+	}, "")
+	if err != nil {
+		t.Fatalf("ExtractPath failed: %v", err)
+	}
+	if result != "/mod/a" {
+		t.Errorf("got %q, want %q", result, "/mod/a")
+	}
+}
+
+func TestExtractPath_GoModuleCache_NoVersion(t *testing.T) {
+	output := `{"Path": "example.com/main", "Main": true}`
+	_, err := ExtractPath(output, &definitions.Extract{Type: "go_module_cache"}, "")
+	if err == nil {
+		t.Error("expected error for module without version, got nil")
+	}
+}
+
 func TestExtractPath_LinePrefix(t *testing.T) {
 	output := `Name: requests
 Version: 2.28.1
@@ -133,6 +267,53 @@ func TestExtractPath_JSONArray(t *testing.T) {
 	}
 }
 
+func TestExtractPath_JSONArray_MatchMode(t *testing.T) {
+	output := `{
+		"packages": [
+			{"id": "registry+https://github.com/rust-lang/crates.io-index#serde_json@1.0.0", "manifest_path": "/src/serde_json/Cargo.toml"},
+			{"id": "registry+https://github.com/rust-lang/crates.io-index#serde@1.0.0", "manifest_path": "/src/serde/Cargo.toml"}
+		]
+	}`
+
+	tests := []struct {
+		mode    string
+		pkg     string
+		want    string
+		wantErr bool
+	}{
+		{"exact", "registry+https://github.com/rust-lang/crates.io-index#serde@1.0.0", "/src/serde/Cargo.toml", false},
+		{"exact", "serde", "", true},
+		{"contains", "#serde@", "/src/serde/Cargo.toml", false},
+		{"prefix", "registry+https://github.com/rust-lang/crates.io-index#serde_json", "/src/serde_json/Cargo.toml", false},
+		{"prefix", "serde", "", true},
+		{"fuzzy", "serde", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode+"/"+tt.pkg, func(t *testing.T) {
+			result, err := ExtractPath(output, &definitions.Extract{
+				Type:         "json_array",
+				ArrayField:   "packages",
+				MatchField:   "id",
+				MatchMode:    tt.mode,
+				ExtractField: "manifest_path",
+			}, tt.pkg)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %q", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ExtractPath failed: %v", err)
+			}
+			if result != tt.want {
+				t.Errorf("got %q, want %q", result, tt.want)
+			}
+		})
+	}
+}
+
 func TestExtractPath_JSONArray_NotFound(t *testing.T) {
 	output := `{
 		"packages": [
@@ -172,6 +353,43 @@ func TestExtractPath_JSONArray_StripFilename(t *testing.T) {
 	}
 }
 
+func TestExtractPath_TSV(t *testing.T) {
+	output := "# packages in environment at /opt/conda:\n#\nnumpy\t1.26.4\t/opt/conda/pkgs/numpy-1.26.4\n"
+	result, err := ExtractPath(output, &definitions.Extract{
+		Type:   "tsv",
+		Column: 2,
+	}, "numpy")
+	if err != nil {
+		t.Fatalf("ExtractPath failed: %v", err)
+	}
+	expected := "/opt/conda/pkgs/numpy-1.26.4"
+	if result != expected {
+		t.Errorf("got %q, want %q", result, expected)
+	}
+}
+
+func TestExtractPath_CSVCustomDelimiter(t *testing.T) {
+	output := "requests;2.31.0;/usr/lib/python3/site-packages\n"
+	result, err := ExtractPath(output, &definitions.Extract{
+		Type:      "csv",
+		Column:    1,
+		Delimiter: ";",
+	}, "")
+	if err != nil {
+		t.Fatalf("ExtractPath failed: %v", err)
+	}
+	if result != "2.31.0" {
+		t.Errorf("got %q, want %q", result, "2.31.0")
+	}
+}
+
+func TestExtractPath_CSV_ColumnOutOfRange(t *testing.T) {
+	_, err := ExtractPath("a,b\n", &definitions.Extract{Type: "csv", Column: 5}, "")
+	if err == nil {
+		t.Error("expected error for missing column, got nil")
+	}
+}
+
 func TestExtractPath_UnknownType(t *testing.T) {
 	_, err := ExtractPath("output", &definitions.Extract{Type: "invalid"}, "")
 	if err == nil {
@@ -2,16 +2,100 @@ package managers
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/git-pkgs/managers/definitions"
+	"github.com/git-pkgs/managers/internal/version"
 )
 
 type GenericManager struct {
-	def        *definitions.Definition
-	dir        string
-	translator *Translator
-	runner     Runner
+	def          *definitions.Definition
+	dir          string
+	manifestFile string
+	translator   *Translator
+	runner       Runner
+
+	// warnings may be appended by operations running on other goroutines
+	warningsMu sync.RWMutex
 	warnings   []string
+
+	// binary replaces the definition's binary in built commands, for
+	// project-local executables such as ./gradlew. Empty uses the definition.
+	binary string
+
+	// brewfilePath points brew bundle at a Brewfile other than ./Brewfile.
+	brewfilePath string
+
+	// detectVersion reports the installed binary version so commands with
+	// requires_version can be checked. Nil skips the check.
+	detectVersion func(*definitions.Definition) (string, error)
+	versionOnce   sync.Once
+	version       string
+}
+
+// GenericManagerOption configures a GenericManager built by NewGenericManager.
+type GenericManagerOption func(*GenericManager)
+
+// WithRunner sets the runner commands are executed with.
+func WithRunner(runner Runner) GenericManagerOption {
+	return func(m *GenericManager) {
+		m.runner = runner
+	}
+}
+
+// WithTranslator sets the translator commands are built with. The
+// definition is registered with it if it doesn't know the manager yet.
+func WithTranslator(translator *Translator) GenericManagerOption {
+	return func(m *GenericManager) {
+		m.translator = translator
+	}
+}
+
+// WithDir overrides the directory commands run in.
+func WithDir(dir string) GenericManagerOption {
+	return func(m *GenericManager) {
+		m.dir = dir
+	}
+}
+
+// WithBrewfilePath makes install read the Brewfile at path, relative to the
+// manager's directory, instead of ./Brewfile. Managers other than brew
+// ignore it.
+func WithBrewfilePath(path string) GenericManagerOption {
+	return func(m *GenericManager) {
+		m.brewfilePath = path
+	}
+}
+
+// NewGenericManager creates a manager for def in dir without going through
+// detection, for callers that already know which manager to use. Commands
+// run with an ExecRunner and a translator holding only def unless options
+// say otherwise. No binary version checks are made.
+func NewGenericManager(def *definitions.Definition, dir string, opts ...GenericManagerOption) *GenericManager {
+	m := &GenericManager{
+		def: def,
+		dir: dir,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	if m.translator == nil {
+		m.translator = NewTranslator()
+	}
+	if _, ok := m.translator.Definition(def.Name); !ok {
+		m.translator.Register(def)
+	}
+	if m.runner == nil {
+		m.runner = NewExecRunner()
+	}
+	return m
 }
 
 func (m *GenericManager) Name() string {
@@ -26,50 +110,126 @@ func (m *GenericManager) Dir() string {
 	return m.dir
 }
 
+// Definition returns the definition the manager was built from.
+func (m *GenericManager) Definition() *definitions.Definition {
+	return m.def
+}
+
+// Warnings returns the non-fatal issues recorded since the manager was
+// created or ClearWarnings was last called: requested flags the definition
+// doesn't support, versions that couldn't be checked, and exit codes the
+// definition doesn't describe.
+//
+// A GenericManager may run operations from several goroutines at once;
+// warnings from all of them are collected here.
 func (m *GenericManager) Warnings() []string {
-	return m.warnings
+	m.warningsMu.RLock()
+	defer m.warningsMu.RUnlock()
+	return slices.Clone(m.warnings)
+}
+
+// ClearWarnings discards all recorded warnings.
+func (m *GenericManager) ClearWarnings() {
+	m.warningsMu.Lock()
+	defer m.warningsMu.Unlock()
+	m.warnings = nil
+}
+
+func (m *GenericManager) warn(format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	m.warningsMu.Lock()
+	defer m.warningsMu.Unlock()
+	m.warnings = append(m.warnings, msg)
+}
+
+// includePlatformFlags maps the keys accepted in InstallOptions.IncludePlatforms
+// to the flags definitions use for them. Each flag has a fixed prefix in the
+// definition, so only these settings can reach the command line.
+var includePlatformFlags = map[string]string{
+	"os":   "include_os",
+	"cpu":  "include_cpu",
+	"libc": "include_libc",
+}
+
+// addIncludePlatforms splits key=value platforms into their include_os,
+// include_cpu and include_libc flags, rejecting any other key.
+func addIncludePlatforms(flags map[string]any, platforms []string) error {
+	for _, platform := range platforms {
+		key, val, ok := strings.Cut(platform, "=")
+		flag, known := includePlatformFlags[key]
+		if !ok || !known || val == "" {
+			return fmt.Errorf("%w: include platform %q (want os=, cpu= or libc=)", ErrUnsupportedOption, platform)
+		}
+		list, _ := flags[flag].([]string)
+		flags[flag] = append(list, val)
+	}
+	return nil
 }
 
 func (m *GenericManager) Install(ctx context.Context, opts InstallOptions) (*Result, error) {
 	input := CommandInput{
 		Args: map[string]string{},
 		Flags: map[string]any{
-			"frozen":     opts.Frozen,
-			"clean":      opts.Clean,
-			"production": opts.Production,
+			"frozen":         opts.Frozen,
+			"clean":          opts.Clean,
+			"production":     opts.Production,
+			"workspaces":     opts.Workspaces,
+			"no_upgrade":     opts.NoUpgrade,
+			"ignore_scripts": opts.IgnoreScripts,
+			"build_missing":  opts.BuildMissing,
+			"include_groups": opts.IncludeGroups,
 		},
 	}
+	if m.brewfilePath != "" {
+		input.Args["brewfile_path"] = m.brewfilePath
+	}
+	if err := addIncludePlatforms(input.Flags, opts.IncludePlatforms); err != nil {
+		return nil, err
+	}
 
-	cmd, err := m.translator.BuildCommand(m.def.Name, "install", input)
+	cmd, err := m.buildCommand("install", input)
 	if err != nil {
 		return nil, err
 	}
 
-	return m.runner.Run(ctx, m.dir, cmd...)
+	return m.run(ctx, "install", input, cmd)
 }
 
 func (m *GenericManager) Add(ctx context.Context, pkg string, opts AddOptions) (*Result, error) {
+	// A named group replaces the dev group rather than adding to it
+	dev := opts.Dev && opts.Group == ""
+
 	input := CommandInput{
 		Args: map[string]string{
 			"package": pkg,
 		},
 		Flags: map[string]any{
-			"dev":       opts.Dev,
-			"optional":  opts.Optional,
-			"exact":     opts.Exact,
-			"workspace": opts.Workspace,
+			"dev":            dev,
+			"optional":       opts.Optional,
+			"peer":           opts.Peer,
+			"exact":          opts.Exact,
+			"workspace":      opts.Workspace,
+			"group":          opts.Group,
+			"user_install":   opts.UserInstall,
+			"features":       opts.Features,
+			"ignore_scripts": opts.IgnoreScripts,
 		},
 	}
+	// Some managers name the workspace inside the command rather than in a
+	// flag (yarn workspace <name> add), so it is passed as an arg as well
+	if opts.Workspace != "" {
+		input.Args["workspace"] = opts.Workspace
+	}
 
-	cmd, err := m.translator.BuildCommand(m.def.Name, "add", input)
+	cmd, err := m.buildCommand("add", input)
 	if err != nil {
 		return nil, err
 	}
 
-	return m.runner.Run(ctx, m.dir, cmd...)
+	return m.run(ctx, "add", input, cmd)
 }
 
-func (m *GenericManager) Remove(ctx context.Context, pkg string) (*Result, error) {
+func (m *GenericManager) Remove(ctx context.Context, pkg string, opts RemoveOptions) (*Result, error) {
 	input := CommandInput{
 		Args: map[string]string{
 			"package": pkg,
@@ -77,26 +237,37 @@ func (m *GenericManager) Remove(ctx context.Context, pkg string) (*Result, error
 		Flags: map[string]any{},
 	}
 
-	cmd, err := m.translator.BuildCommand(m.def.Name, "remove", input)
+	if opts.Zap {
+		input.Flags["zap"] = true
+	}
+
+	cmd, err := m.buildCommand("remove", input)
 	if err != nil {
 		return nil, err
 	}
 
-	return m.runner.Run(ctx, m.dir, cmd...)
+	return m.run(ctx, "remove", input, cmd)
 }
 
-func (m *GenericManager) List(ctx context.Context) (*Result, error) {
+func (m *GenericManager) List(ctx context.Context, opts ListOptions) (*Result, error) {
 	input := CommandInput{
-		Args:  map[string]string{},
-		Flags: map[string]any{},
+		Args: map[string]string{},
+		Flags: map[string]any{
+			"json":     opts.JSON,
+			"outdated": opts.OutdatedOnly,
+			"tree":     opts.Tree,
+		},
+	}
+	if opts.Depth > 0 {
+		input.Flags["depth"] = strconv.Itoa(opts.Depth)
 	}
 
-	cmd, err := m.translator.BuildCommand(m.def.Name, "list", input)
+	cmd, err := m.buildCommand("list", input)
 	if err != nil {
 		return nil, err
 	}
 
-	return m.runner.Run(ctx, m.dir, cmd...)
+	return m.run(ctx, "list", input, cmd)
 }
 
 func (m *GenericManager) Outdated(ctx context.Context) (*Result, error) {
@@ -105,30 +276,32 @@ func (m *GenericManager) Outdated(ctx context.Context) (*Result, error) {
 		Flags: map[string]any{},
 	}
 
-	cmd, err := m.translator.BuildCommand(m.def.Name, "outdated", input)
+	cmd, err := m.buildCommand("outdated", input)
 	if err != nil {
 		return nil, err
 	}
 
-	return m.runner.Run(ctx, m.dir, cmd...)
+	return m.run(ctx, "outdated", input, cmd)
 }
 
-func (m *GenericManager) Update(ctx context.Context, pkg string) (*Result, error) {
+func (m *GenericManager) Update(ctx context.Context, pkg string, opts UpdateOptions) (*Result, error) {
 	input := CommandInput{
-		Args:  map[string]string{},
-		Flags: map[string]any{},
+		Args: map[string]string{},
+		Flags: map[string]any{
+			"dry_run": opts.DryRun,
+		},
 	}
 
 	if pkg != "" {
 		input.Args["package"] = pkg
 	}
 
-	cmd, err := m.translator.BuildCommand(m.def.Name, "update", input)
+	cmd, err := m.buildCommand("update", input)
 	if err != nil {
 		return nil, err
 	}
 
-	return m.runner.Run(ctx, m.dir, cmd...)
+	return m.run(ctx, "update", input, cmd)
 }
 
 func (m *GenericManager) Supports(cap Capability) bool {
@@ -157,12 +330,12 @@ func (m *GenericManager) Vendor(ctx context.Context) (*Result, error) {
 		Flags: map[string]any{},
 	}
 
-	cmd, err := m.translator.BuildCommand(m.def.Name, "vendor", input)
+	cmd, err := m.buildCommand("vendor", input)
 	if err != nil {
 		return nil, err
 	}
 
-	return m.runner.Run(ctx, m.dir, cmd...)
+	return m.run(ctx, "vendor", input, cmd)
 }
 
 func (m *GenericManager) Resolve(ctx context.Context) (*Result, error) {
@@ -171,12 +344,121 @@ func (m *GenericManager) Resolve(ctx context.Context) (*Result, error) {
 		Flags: map[string]any{},
 	}
 
-	cmd, err := m.translator.BuildCommand(m.def.Name, "resolve", input)
+	cmd, err := m.buildCommand("resolve", input)
 	if err != nil {
 		return nil, err
 	}
 
-	return m.runner.Run(ctx, m.dir, cmd...)
+	return m.run(ctx, "resolve", input, cmd)
+}
+
+func (m *GenericManager) Clean(ctx context.Context) (*Result, error) {
+	input := CommandInput{
+		Args:  map[string]string{},
+		Flags: map[string]any{},
+	}
+
+	cmd, err := m.buildCommand("clean", input)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.run(ctx, "clean", input, cmd)
+}
+
+// Develop installs the current project in development (editable) mode,
+// such as nimble develop. Few managers have it, so it isn't part of the
+// Manager interface; assert a detected Manager to *GenericManager to use it.
+func (m *GenericManager) Develop(ctx context.Context) (*Result, error) {
+	input := CommandInput{
+		Args:  map[string]string{},
+		Flags: map[string]any{},
+	}
+
+	cmd, err := m.buildCommand("develop", input)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.run(ctx, "develop", input, cmd)
+}
+
+// Exec runs command inside the manager's environment, such as bundle exec.
+// The command is split on whitespace; the first word is the program and the
+// rest are passed through as its arguments. Shell quoting is not interpreted.
+// Like Develop, it isn't part of the Manager interface.
+func (m *GenericManager) Exec(ctx context.Context, command string) (*Result, error) {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return nil, ErrMissingArgument{Argument: "command"}
+	}
+
+	input := CommandInput{
+		Args: map[string]string{
+			"command": fields[0],
+		},
+		Flags: map[string]any{},
+		Extra: fields[1:],
+	}
+
+	cmd, err := m.buildCommand("exec", input)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.run(ctx, "exec", input, cmd)
+}
+
+// Run runs a project script or task, such as npm run or deno task, with
+// args passed through to it. Managers that treat flags after the script as
+// their own (npm) get a "--" before the args from their definition's
+// extra_separator.
+func (m *GenericManager) Run(ctx context.Context, script string, args ...string) (*Result, error) {
+	if script == "" {
+		return nil, ErrMissingArgument{Argument: "script"}
+	}
+
+	input := CommandInput{
+		Args: map[string]string{
+			"script": script,
+		},
+		Flags: map[string]any{},
+		Extra: args,
+	}
+
+	cmd, err := m.buildCommand("run", input)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.run(ctx, "run", input, cmd)
+}
+
+// Search looks up packages matching query in the manager's registry or
+// store, such as snap find or npm search.
+func (m *GenericManager) Search(ctx context.Context, query string, opts SearchOptions) (*Result, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, ErrMissingArgument{Argument: "query"}
+	}
+
+	input := CommandInput{
+		Args: map[string]string{
+			"query": query,
+		},
+		Flags: map[string]any{
+			"json": opts.JSON,
+		},
+	}
+	if opts.Limit > 0 {
+		input.Flags["limit"] = strconv.Itoa(opts.Limit)
+	}
+
+	cmd, err := m.buildCommand("search", input)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.run(ctx, "search", input, cmd)
 }
 
 func (m *GenericManager) Path(ctx context.Context, pkg string) (*PathResult, error) {
@@ -187,12 +469,12 @@ func (m *GenericManager) Path(ctx context.Context, pkg string) (*PathResult, err
 		Flags: map[string]any{},
 	}
 
-	cmd, err := m.translator.BuildCommand(m.def.Name, "path", input)
+	cmd, err := m.buildCommand("path", input)
 	if err != nil {
 		return nil, err
 	}
 
-	result, err := m.runner.Run(ctx, m.dir, cmd...)
+	result, err := m.run(ctx, "path", input, cmd)
 	if err != nil {
 		return nil, err
 	}
@@ -204,7 +486,7 @@ func (m *GenericManager) Path(ctx context.Context, pkg string) (*PathResult, err
 
 	path, err := ExtractPath(result.Stdout, extract, pkg)
 	if err != nil {
-		return &PathResult{Result: result}, err
+		return &PathResult{Result: result, ExtractionError: err}, err
 	}
 
 	return &PathResult{
@@ -212,3 +494,204 @@ func (m *GenericManager) Path(ctx context.Context, pkg string) (*PathResult, err
 		Result: result,
 	}, nil
 }
+
+// buildCommand builds the command for operation and records a warning for
+// each requested flag the definition doesn't know, since the translator
+// drops those silently.
+func (m *GenericManager) buildCommand(operation string, input CommandInput) ([]string, error) {
+	if input.BinaryOverride == "" {
+		input.BinaryOverride = m.binary
+	}
+	cmd, err := m.translator.BuildCommand(m.def.Name, operation, input)
+	if err != nil {
+		return nil, err
+	}
+
+	def := m.def.Commands[operation]
+	var unsupported []string
+	for name, val := range input.Flags {
+		if !isTruthy(val) {
+			continue
+		}
+		if _, ok := def.Flags[name]; ok {
+			continue
+		}
+		if _, ok := def.BaseOverrides[name]; ok {
+			continue
+		}
+		unsupported = append(unsupported, name)
+	}
+	sort.Strings(unsupported)
+	for _, name := range unsupported {
+		m.warn("%s %s does not support the %s flag; it was ignored", m.def.Name, operation, name)
+	}
+
+	return cmd, nil
+}
+
+// operationRunner is implemented by runners that want the full operation,
+// not just the command line, such as PolicyRunner.
+type operationRunner interface {
+	RunWithContext(ctx context.Context, op *PolicyOperation) (*Result, error)
+}
+
+func (m *GenericManager) run(ctx context.Context, operation string, input CommandInput, cmd []string) (*Result, error) {
+	if err := m.checkVersion(operation); err != nil {
+		return nil, err
+	}
+
+	def := m.def.Commands[operation]
+	if len(def.Before) > 0 || len(def.Then) > 0 {
+		return m.runChain(ctx, operation, input, cmd)
+	}
+	return m.runPrimary(ctx, operation, input, cmd)
+}
+
+// runChain runs an operation's before steps, its main command and then its
+// then steps, in the order BuildCommands returns them. A failing step stops
+// the chain unless the definition marks it optional, in which case it is
+// recorded as a warning. The main command's result is returned.
+func (m *GenericManager) runChain(ctx context.Context, operation string, input CommandInput, cmd []string) (*Result, error) {
+	if input.BinaryOverride == "" {
+		input.BinaryOverride = m.binary
+	}
+	cmds, meta, err := m.translator.BuildCommands(m.def.Name, operation, input)
+	if err != nil {
+		return nil, err
+	}
+	primary := m.translator.primaryIndex(m.def.Name, operation)
+
+	var primaryResult *Result
+	for i, step := range cmds {
+		if i == primary {
+			result, err := m.runPrimary(ctx, operation, input, cmd)
+			if err != nil || m.failed(operation, result) {
+				return result, err
+			}
+			primaryResult = result
+			continue
+		}
+
+		result, err := m.exec(ctx, operation, input, step)
+		if err == nil && !m.failed(operation, result) {
+			continue
+		}
+		if meta.Steps[i].Optional {
+			m.warn("%s %s: optional step %q failed; continuing", m.def.Name, operation, meta.Steps[i].Label)
+			continue
+		}
+		return result, err
+	}
+	return primaryResult, nil
+}
+
+// failed reports whether a step's exit code is one the operation's
+// exit_codes don't describe as a normal outcome.
+func (m *GenericManager) failed(operation string, result *Result) bool {
+	if result == nil || result.ExitCode == 0 {
+		return false
+	}
+	meaning, ok := m.def.Commands[operation].ExitCodes[result.ExitCode]
+	return !ok || meaning == "error"
+}
+
+// exec hands cmd to the runner, with the full operation when the runner
+// wants it.
+func (m *GenericManager) exec(ctx context.Context, operation string, input CommandInput, cmd []string) (*Result, error) {
+	if r, ok := m.runner.(operationRunner); ok {
+		return r.RunWithContext(ctx, m.policyOperation(operation, input, cmd))
+	}
+	return m.runner.Run(ctx, m.dir, cmd...)
+}
+
+// runPrimary runs an operation's main command and turns its output into a
+// typed error where the definition's error_patterns say so.
+func (m *GenericManager) runPrimary(ctx context.Context, operation string, input CommandInput, cmd []string) (*Result, error) {
+	result, err := m.exec(ctx, operation, input, cmd)
+
+	if result != nil && result.ExitCode > 0 {
+		codes := m.def.Commands[operation].ExitCodes
+		if _, ok := codes[result.ExitCode]; len(codes) > 0 && !ok {
+			m.warn("%s %s exited with code %d, which its definition doesn't describe", m.def.Name, operation, result.ExitCode)
+		}
+	}
+	// A pattern explains a failing exit code better than ErrCommandFailed, but
+	// any other runner error (the binary missing, a policy refusing the
+	// command) is kept as is
+	var failed ErrCommandFailed
+	if result != nil && (err == nil || errors.As(err, &failed)) {
+		if perr := m.patternError(operation, input, result); perr != nil {
+			return result, perr
+		}
+	}
+	return result, err
+}
+
+// patternError returns the typed error for the first of the command's
+// error_patterns that matches stderr or stdout. Managers such as pip exit 0
+// when asked to remove something that isn't installed, and others exit 1 for
+// any failure, so the exit code alone can't tell.
+func (m *GenericManager) patternError(operation string, input CommandInput, result *Result) error {
+	for _, pattern := range m.def.Commands[operation].ErrorPatterns {
+		if !pattern.MatchString(result.Stderr) && !pattern.MatchString(result.Stdout) {
+			continue
+		}
+		if pattern.Error == definitions.ErrorPackageNotFound {
+			return ErrPackageNotFound{Package: input.Args["package"], Manager: m.def.Name}
+		}
+	}
+	return nil
+}
+
+// checkVersion returns ErrUnsupportedVersion if the operation declares a
+// requires_version newer than the installed binary. If the installed version
+// can't be determined the command is allowed to run.
+func (m *GenericManager) checkVersion(operation string) error {
+	required := m.def.Commands[operation].RequiresVersion
+	if required == "" || m.detectVersion == nil {
+		return nil
+	}
+
+	m.versionOnce.Do(func() {
+		m.version, _ = m.detectVersion(m.def)
+		if m.version == "" {
+			m.warn("could not determine %s version; skipping requires_version checks", m.def.Name)
+		}
+	})
+	if m.version == "" || version.Compare(m.version, required) >= 0 {
+		return nil
+	}
+
+	return ErrUnsupportedVersion{
+		Manager:  m.def.Name,
+		Version:  m.version,
+		Required: required,
+	}
+}
+
+func (m *GenericManager) policyOperation(operation string, input CommandInput, cmd []string) *PolicyOperation {
+	op := &PolicyOperation{
+		Manager:      m.def.Name,
+		Operation:    operation,
+		Args:         make(map[string]string, len(input.Args)),
+		Flags:        make(map[string]any, len(input.Flags)),
+		WorkingDir:   m.dir,
+		ManifestFile: m.manifestFile,
+		Command:      cmd,
+	}
+	for k, v := range input.Args {
+		op.Args[k] = v
+	}
+	for k, v := range input.Flags {
+		op.Flags[k] = v
+	}
+	if pkg := input.Args["package"]; pkg != "" {
+		op.Packages = []string{pkg}
+	}
+	if m.def.Name == "gomod" {
+		if v := readGoDirective(m.dir); v != "" {
+			op.Args["go_version"] = v
+		}
+	}
+	return op
+}
@@ -3,19 +3,42 @@ package managers
 import (
 	"context"
 	"errors"
+	"os"
+	"path/filepath"
+	"sync"
 	"testing"
 
 	"github.com/git-pkgs/managers/definitions"
+	"github.com/git-pkgs/managers/policies"
 )
 
-func newTestManager(def *definitions.Definition, runner *MockRunner) *GenericManager {
-	translator := NewTranslator()
-	translator.Register(def)
-	return &GenericManager{
-		def:        def,
-		dir:        "/test/project",
-		translator: translator,
-		runner:     runner,
+func embeddedDefinition(t *testing.T, name string) *definitions.Definition {
+	t.Helper()
+	defs, err := definitions.LoadEmbedded()
+	if err != nil {
+		t.Fatalf("failed to load definitions: %v", err)
+	}
+	for _, def := range defs {
+		if def.Name == name {
+			return def
+		}
+	}
+	t.Fatalf("no embedded definition for %s", name)
+	return nil
+}
+
+func TestGenericManager_Definition(t *testing.T) {
+	def := &definitions.Definition{
+		Name:   "testpkg",
+		Binary: "testpkg",
+		Detection: definitions.Detection{
+			Lockfiles: []string{"test.lock"},
+		},
+	}
+
+	mgr := NewGenericManager(def, "/test/project", WithRunner(NewMockRunner()))
+	if mgr.Definition() != def {
+		t.Errorf("expected Definition to return the manager's definition")
 	}
 }
 
@@ -40,7 +63,7 @@ func TestGenericManager_Path_Raw(t *testing.T) {
 		Stdout:   "/usr/local/lib/testpkg/lodash\n",
 	}}
 
-	mgr := newTestManager(def, runner)
+	mgr := NewGenericManager(def, "/test/project", WithRunner(runner))
 	result, err := mgr.Path(context.Background(), "lodash")
 	if err != nil {
 		t.Fatalf("Path failed: %v", err)
@@ -53,10 +76,7 @@ func TestGenericManager_Path_Raw(t *testing.T) {
 	if len(runner.Captured) != 1 {
 		t.Fatalf("expected 1 command, got %d", len(runner.Captured))
 	}
-	expected := []string{"testpkg", "show", "--path", "lodash"}
-	if !slicesEqual(runner.Captured[0], expected) {
-		t.Errorf("got command %v, want %v", runner.Captured[0], expected)
-	}
+	runner.AssertCalled(t, "testpkg", "show", "--path", "lodash")
 }
 
 func TestGenericManager_Path_JSON(t *testing.T) {
@@ -79,12 +99,12 @@ func TestGenericManager_Path_JSON(t *testing.T) {
 	}
 
 	runner := NewMockRunner()
-	runner.Results = []*Result{{
+	runner.OnArgs([]string{"go", "list", "-m", "-json", "github.com/pkg/errors"}, &Result{
 		ExitCode: 0,
 		Stdout:   `{"Path": "github.com/pkg/errors", "Dir": "/home/user/go/pkg/mod/github.com/pkg/errors@v0.9.1"}`,
-	}}
+	}, nil)
 
-	mgr := newTestManager(def, runner)
+	mgr := NewGenericManager(def, "/test/project", WithRunner(runner))
 	result, err := mgr.Path(context.Background(), "github.com/pkg/errors")
 	if err != nil {
 		t.Fatalf("Path failed: %v", err)
@@ -125,7 +145,7 @@ Location: /usr/local/lib/python3.9/site-packages
 Requires: certifi, charset-normalizer`,
 	}}
 
-	mgr := newTestManager(def, runner)
+	mgr := NewGenericManager(def, "/test/project", WithRunner(runner))
 	result, err := mgr.Path(context.Background(), "requests")
 	if err != nil {
 		t.Fatalf("Path failed: %v", err)
@@ -162,7 +182,7 @@ func TestGenericManager_Path_Template(t *testing.T) {
 		Stdout:   "whatever output, ignored for template",
 	}}
 
-	mgr := newTestManager(def, runner)
+	mgr := NewGenericManager(def, "/test/project", WithRunner(runner))
 	result, err := mgr.Path(context.Background(), "lodash")
 	if err != nil {
 		t.Fatalf("Path failed: %v", err)
@@ -173,9 +193,45 @@ func TestGenericManager_Path_Template(t *testing.T) {
 	}
 
 	// extraction_only means package arg is not passed to command
-	expected := []string{"yarn", "why"}
-	if !slicesEqual(runner.Captured[0], expected) {
-		t.Errorf("got command %v, want %v", runner.Captured[0], expected)
+	runner.AssertCalled(t, "yarn", "why")
+}
+
+// Scoped names are substituted into the template as-is. This pins the
+// node_modules layout; Plug'n'Play installs have no node_modules directory
+// and aren't covered by the template.
+func TestYarnPathScopedPackage(t *testing.T) {
+	runner := NewMockRunner()
+	runner.Results = []*Result{{
+		ExitCode: 0,
+		Stdout:   "├─ @types/node@20.11.5\n",
+	}}
+
+	mgr := NewGenericManager(embeddedDefinition(t, "yarn"), "/test/project", WithRunner(runner))
+	result, err := mgr.Path(context.Background(), "@types/node")
+	if err != nil {
+		t.Fatalf("Path failed: %v", err)
+	}
+
+	if result.Path != "node_modules/@types/node" {
+		t.Errorf("got path %q, want %q", result.Path, "node_modules/@types/node")
+	}
+
+	runner.AssertCalled(t, "yarn", "list", "--depth=0")
+}
+
+func TestGomodPathWithReplace(t *testing.T) {
+	runner := NewMockRunner()
+	runner.OnArgs([]string{"go", "list", "-m", "-json", "example.com/foo"}, &Result{
+		Stdout: `{"Path": "example.com/foo", "Version": "v1.2.0", "Replace": {"Path": "../foo", "Dir": "/home/user/src/foo"}}`,
+	}, nil)
+
+	mgr := NewGenericManager(embeddedDefinition(t, "gomod"), "/test/project", WithRunner(runner))
+	result, err := mgr.Path(context.Background(), "example.com/foo")
+	if err != nil {
+		t.Fatalf("Path failed: %v", err)
+	}
+	if result.Path != "/home/user/src/foo" {
+		t.Errorf("got path %q, want %q", result.Path, "/home/user/src/foo")
 	}
 }
 
@@ -212,7 +268,7 @@ func TestGenericManager_Path_JSONArray(t *testing.T) {
 		}`,
 	}}
 
-	mgr := newTestManager(def, runner)
+	mgr := NewGenericManager(def, "/test/project", WithRunner(runner))
 	result, err := mgr.Path(context.Background(), "serde")
 	if err != nil {
 		t.Fatalf("Path failed: %v", err)
@@ -242,7 +298,7 @@ func TestGenericManager_Path_RunnerError(t *testing.T) {
 	runner := NewMockRunner()
 	runner.Errors = []error{errors.New("command not found")}
 
-	mgr := newTestManager(def, runner)
+	mgr := NewGenericManager(def, "/test/project", WithRunner(runner))
 	_, err := mgr.Path(context.Background(), "lodash")
 	if err == nil {
 		t.Error("expected error, got nil")
@@ -274,14 +330,20 @@ func TestGenericManager_Path_ExtractionError(t *testing.T) {
 		Stdout:   "no location line here",
 	}}
 
-	mgr := newTestManager(def, runner)
+	mgr := NewGenericManager(def, "/test/project", WithRunner(runner))
 	result, err := mgr.Path(context.Background(), "lodash")
 	if err == nil {
 		t.Error("expected extraction error, got nil")
 	}
 	// Result should still be returned even on extraction error
 	if result == nil || result.Result == nil {
-		t.Error("expected result to be returned even on extraction error")
+		t.Fatal("expected result to be returned even on extraction error")
+	}
+	if result.ExtractionError != err {
+		t.Errorf("got ExtractionError %v, want %v", result.ExtractionError, err)
+	}
+	if result.Result.Stdout != "no location line here" {
+		t.Errorf("got stdout %q, want raw output preserved", result.Result.Stdout)
 	}
 }
 
@@ -298,7 +360,7 @@ func TestGenericManager_Path_NoPathCommand(t *testing.T) {
 	}
 
 	runner := NewMockRunner()
-	mgr := newTestManager(def, runner)
+	mgr := NewGenericManager(def, "/test/project", WithRunner(runner))
 	_, err := mgr.Path(context.Background(), "lodash")
 	if err == nil {
 		t.Error("expected error for missing path command, got nil")
@@ -323,7 +385,7 @@ func TestGenericManager_Vendor(t *testing.T) {
 		Stdout:   "",
 	}}
 
-	mgr := newTestManager(def, runner)
+	mgr := NewGenericManager(def, "/test/project", WithRunner(runner))
 	result, err := mgr.Vendor(context.Background())
 	if err != nil {
 		t.Fatalf("Vendor failed: %v", err)
@@ -336,10 +398,7 @@ func TestGenericManager_Vendor(t *testing.T) {
 	if len(runner.Captured) != 1 {
 		t.Fatalf("expected 1 command, got %d", len(runner.Captured))
 	}
-	expected := []string{"go", "mod", "vendor"}
-	if !slicesEqual(runner.Captured[0], expected) {
-		t.Errorf("got command %v, want %v", runner.Captured[0], expected)
-	}
+	runner.AssertCalled(t, "go", "mod", "vendor")
 }
 
 func TestGenericManager_Vendor_NoCommand(t *testing.T) {
@@ -355,7 +414,7 @@ func TestGenericManager_Vendor_NoCommand(t *testing.T) {
 	}
 
 	runner := NewMockRunner()
-	mgr := newTestManager(def, runner)
+	mgr := NewGenericManager(def, "/test/project", WithRunner(runner))
 	_, err := mgr.Vendor(context.Background())
 	if err == nil {
 		t.Error("expected error for missing vendor command, got nil")
@@ -381,7 +440,7 @@ func TestGenericManager_Resolve(t *testing.T) {
 		Stdout:   `{"dependencies": {}}`,
 	}}
 
-	mgr := newTestManager(def, runner)
+	mgr := NewGenericManager(def, "/test/project", WithRunner(runner))
 	result, err := mgr.Resolve(context.Background())
 	if err != nil {
 		t.Fatalf("Resolve failed: %v", err)
@@ -394,10 +453,7 @@ func TestGenericManager_Resolve(t *testing.T) {
 	if len(runner.Captured) != 1 {
 		t.Fatalf("expected 1 command, got %d", len(runner.Captured))
 	}
-	expected := []string{"npm", "ls", "--depth", "Infinity", "--json", "--long"}
-	if !slicesEqual(runner.Captured[0], expected) {
-		t.Errorf("got command %v, want %v", runner.Captured[0], expected)
-	}
+	runner.AssertCalled(t, "npm", "ls", "--depth", "Infinity", "--json", "--long")
 }
 
 func TestGenericManager_Resolve_NoCommand(t *testing.T) {
@@ -413,7 +469,7 @@ func TestGenericManager_Resolve_NoCommand(t *testing.T) {
 	}
 
 	runner := NewMockRunner()
-	mgr := newTestManager(def, runner)
+	mgr := NewGenericManager(def, "/test/project", WithRunner(runner))
 	_, err := mgr.Resolve(context.Background())
 	if err == nil {
 		t.Error("expected error for missing resolve command, got nil")
@@ -435,13 +491,711 @@ func TestGenericManager_Resolve_RunnerError(t *testing.T) {
 	runner := NewMockRunner()
 	runner.Errors = []error{errors.New("command not found")}
 
-	mgr := newTestManager(def, runner)
+	mgr := NewGenericManager(def, "/test/project", WithRunner(runner))
 	_, err := mgr.Resolve(context.Background())
 	if err == nil {
 		t.Error("expected error, got nil")
 	}
 }
 
+func TestGenericManager_Clean(t *testing.T) {
+	def := &definitions.Definition{
+		Name:   "pnpm",
+		Binary: "pnpm",
+		Commands: map[string]definitions.Command{
+			"clean": {
+				Base: []string{"store", "prune"},
+			},
+		},
+		Capabilities: []string{"clean"},
+	}
+
+	runner := NewMockRunner()
+	mgr := NewGenericManager(def, "/test/project", WithRunner(runner))
+	if _, err := mgr.Clean(context.Background()); err != nil {
+		t.Fatalf("Clean failed: %v", err)
+	}
+
+	if len(runner.Captured) != 1 {
+		t.Fatalf("expected 1 command, got %d", len(runner.Captured))
+	}
+	runner.AssertCalled(t, "pnpm", "store", "prune")
+	if !mgr.Supports(CapClean) {
+		t.Error("expected manager to support CapClean")
+	}
+}
+
+func TestGenericManager_Clean_NoCommand(t *testing.T) {
+	def := &definitions.Definition{
+		Name:   "testpkg",
+		Binary: "testpkg",
+		Commands: map[string]definitions.Command{
+			"install": {
+				Base: []string{"install"},
+			},
+		},
+		Capabilities: []string{"install"},
+	}
+
+	runner := NewMockRunner()
+	mgr := NewGenericManager(def, "/test/project", WithRunner(runner))
+	_, err := mgr.Clean(context.Background())
+	if !errors.Is(err, ErrUnsupportedOperation) {
+		t.Errorf("expected ErrUnsupportedOperation, got %v", err)
+	}
+}
+
+func TestGenericManager_Develop(t *testing.T) {
+	def := &definitions.Definition{
+		Name:   "nimble",
+		Binary: "nimble",
+		Commands: map[string]definitions.Command{
+			"develop": {
+				Base: []string{"develop"},
+			},
+		},
+		Capabilities: []string{"develop"},
+	}
+
+	runner := NewMockRunner()
+	mgr := NewGenericManager(def, "/test/project", WithRunner(runner))
+	if _, err := mgr.Develop(context.Background()); err != nil {
+		t.Fatalf("Develop failed: %v", err)
+	}
+
+	expected := []string{"nimble", "develop"}
+	if !slicesEqual(runner.LastCaptured(), expected) {
+		t.Errorf("got command %v, want %v", runner.LastCaptured(), expected)
+	}
+	if !mgr.Supports(CapDevelop) {
+		t.Error("expected manager to support CapDevelop")
+	}
+}
+
+func TestGenericManager_Update_DryRun(t *testing.T) {
+	def := &definitions.Definition{
+		Name:   "poetry",
+		Binary: "poetry",
+		Commands: map[string]definitions.Command{
+			"update": {
+				Base: []string{"update"},
+				Args: map[string]definitions.Arg{
+					"package": {Position: 0},
+				},
+				Flags: map[string]definitions.Flag{
+					"dry_run": {Values: []definitions.FlagValue{{Literal: "--dry-run"}}},
+				},
+			},
+		},
+		Capabilities: []string{"update"},
+	}
+
+	runner := NewMockRunner()
+	mgr := NewGenericManager(def, "/test/project", WithRunner(runner))
+	if _, err := mgr.Update(context.Background(), "requests", UpdateOptions{DryRun: true}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	expected := []string{"poetry", "update", "requests", "--dry-run"}
+	if !slicesEqual(runner.LastCaptured(), expected) {
+		t.Errorf("got command %v, want %v", runner.LastCaptured(), expected)
+	}
+}
+
+func TestGenericManager_Remove_Zap(t *testing.T) {
+	runner := NewMockRunner()
+	mgr := NewGenericManager(embeddedDefinition(t, "brew"), "/test/project", WithRunner(runner))
+	if _, err := mgr.Remove(context.Background(), "visual-studio-code", RemoveOptions{Zap: true}); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+
+	runner.AssertCalled(t, "brew", "uninstall", "visual-studio-code", "--zap")
+}
+
+func TestGenericManager_Remove_PackageNotFound(t *testing.T) {
+	runner := NewMockRunner()
+	runner.OnArgs([]string{"pip", "uninstall", "--yes", "nonexistent"}, &Result{
+		Stderr: "WARNING: Skipping nonexistent as it is not installed.\n",
+	}, nil)
+	mgr := NewGenericManager(embeddedDefinition(t, "pip"), "/test/project", WithRunner(runner))
+
+	result, err := mgr.Remove(context.Background(), "nonexistent", RemoveOptions{})
+	var notFound ErrPackageNotFound
+	if !errors.As(err, &notFound) {
+		t.Fatalf("got error %v, want ErrPackageNotFound", err)
+	}
+	if notFound.Package != "nonexistent" || notFound.Manager != "pip" {
+		t.Errorf("got %+v, want package nonexistent from pip", notFound)
+	}
+	if result == nil {
+		t.Error("expected the result to be returned with the error")
+	}
+
+	// Output that doesn't match a pattern leaves the removal successful
+	if _, err := mgr.Remove(context.Background(), "requests", RemoveOptions{}); err != nil {
+		t.Errorf("Remove failed: %v", err)
+	}
+
+	// A runner error is kept even if the output also matches
+	failed := errors.New("sudo: a password is required")
+	runner.OnArgs([]string{"pip", "uninstall", "--yes", "flask"}, &Result{
+		Stderr: "WARNING: Skipping flask as it is not installed.\n",
+	}, failed)
+	if _, err := mgr.Remove(context.Background(), "flask", RemoveOptions{}); !errors.Is(err, failed) {
+		t.Errorf("got %v, want the runner error", err)
+	}
+}
+
+func TestGenericManager_Remove_PackageNotFoundExitCode(t *testing.T) {
+	def := embeddedDefinition(t, "brew")
+	mock := NewMockRunner()
+	mock.OnArgs([]string{"brew", "uninstall", "foo"}, &Result{
+		ExitCode: 1,
+		Stderr:   "Error: No such keg: /usr/local/Cellar/foo\n",
+	}, nil)
+	mock.OnArgs([]string{"brew", "uninstall", "bar"}, &Result{
+		ExitCode: 1,
+		Stderr:   "Error: Permission denied @ apply2files\n",
+	}, nil)
+	mgr := NewGenericManager(def, "/test/project", WithRunner(NewExitCodeAwareRunner(mock, def)))
+
+	// The pattern replaces the ErrCommandFailed for the failing exit code
+	_, err := mgr.Remove(context.Background(), "foo", RemoveOptions{})
+	var notFound ErrPackageNotFound
+	if !errors.As(err, &notFound) {
+		t.Fatalf("got error %v, want ErrPackageNotFound", err)
+	}
+	if notFound.Package != "foo" || notFound.Manager != "brew" {
+		t.Errorf("got %+v, want package foo from brew", notFound)
+	}
+
+	// Without a match the exit code is still reported
+	_, err = mgr.Remove(context.Background(), "bar", RemoveOptions{})
+	var failed ErrCommandFailed
+	if !errors.As(err, &failed) || failed.ExitCode != 1 {
+		t.Errorf("got error %v, want ErrCommandFailed with exit code 1", err)
+	}
+}
+
+func TestGenericManager_Add_Workspace(t *testing.T) {
+	tests := []struct {
+		manager  string
+		expected []string
+	}{
+		{"npm", []string{"npm", "install", "lodash", "--workspace", "web"}},
+		{"pnpm", []string{"pnpm", "add", "lodash", "--filter", "web"}},
+		{"yarn", []string{"yarn", "workspace", "web", "add", "lodash"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.manager, func(t *testing.T) {
+			runner := NewMockRunner()
+			mgr := NewGenericManager(embeddedDefinition(t, tt.manager), "/test/project", WithRunner(runner))
+			if _, err := mgr.Add(context.Background(), "lodash", AddOptions{Workspace: "web"}); err != nil {
+				t.Fatalf("Add failed: %v", err)
+			}
+			if !slicesEqual(runner.LastCaptured(), tt.expected) {
+				t.Errorf("got command %v, want %v", runner.LastCaptured(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestGenericManager_Install_IncludePlatforms(t *testing.T) {
+	runner := NewMockRunner()
+	mgr := NewGenericManager(embeddedDefinition(t, "npm"), "/test/project", WithRunner(runner))
+	opts := InstallOptions{IncludePlatforms: []string{"os=linux", "cpu=x64", "os=darwin"}}
+	if _, err := mgr.Install(context.Background(), opts); err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+	expected := []string{"npm", "install", "--cpu=x64", "--os=linux", "--os=darwin"}
+	if !slicesEqual(runner.LastCaptured(), expected) {
+		t.Errorf("got command %v, want %v", runner.LastCaptured(), expected)
+	}
+
+	for _, platform := range []string{"registry=https://evil.example", "linux", "os="} {
+		runner := NewMockRunner()
+		mgr := NewGenericManager(embeddedDefinition(t, "npm"), "/test/project", WithRunner(runner))
+		_, err := mgr.Install(context.Background(), InstallOptions{IncludePlatforms: []string{platform}})
+		if !errors.Is(err, ErrUnsupportedOption) {
+			t.Errorf("%q: expected ErrUnsupportedOption, got %v", platform, err)
+		}
+		if len(runner.Captured) != 0 {
+			t.Errorf("%q: expected command not to run, got %v", platform, runner.Captured)
+		}
+	}
+}
+
+func TestGenericManager_Install_BeforeSteps(t *testing.T) {
+	runner := NewMockRunner()
+	mgr := NewGenericManager(embeddedDefinition(t, "apt"), "/test/project", WithRunner(runner))
+	if _, err := mgr.Install(context.Background(), InstallOptions{}); err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+	runner.AssertCalled(t, "apt-get", "update")
+	runner.AssertCalled(t, "apt-get", "install", "--yes")
+	if len(runner.Captured) != 2 || runner.Captured[0][1] != "update" {
+		t.Errorf("expected apt-get update before install, got %v", runner.Captured)
+	}
+
+	// A failing before step stops the operation
+	runner = NewMockRunner()
+	runner.OnArgs([]string{"apt-get", "update"}, &Result{ExitCode: 100}, nil)
+	mgr = NewGenericManager(embeddedDefinition(t, "apt"), "/test/project", WithRunner(runner))
+	result, err := mgr.Install(context.Background(), InstallOptions{})
+	if err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+	if result.ExitCode != 100 {
+		t.Errorf("got exit code %d, want 100", result.ExitCode)
+	}
+	runner.AssertCalledNTimes(t, 0, "apt-get", "install", "--yes")
+}
+
+func TestGenericManager_Add_OptionalThenStep(t *testing.T) {
+	def := &definitions.Definition{
+		Name:   "gomod",
+		Binary: "go",
+		Commands: map[string]definitions.Command{
+			"add": {
+				Base: []string{"get"},
+				Args: map[string]definitions.Arg{
+					"package": {Position: 0, Required: true},
+				},
+				Then: []definitions.Command{
+					{Base: []string{"mod", "tidy"}, Optional: true},
+				},
+			},
+		},
+		Capabilities: []string{"add"},
+	}
+
+	runner := NewMockRunner()
+	runner.OnArgs([]string{"go", "get", "example.com/foo"}, &Result{Stdout: "added"}, nil)
+	runner.OnArgs([]string{"go", "mod", "tidy"}, &Result{ExitCode: 1}, nil)
+	mgr := NewGenericManager(def, "/test/project", WithRunner(runner))
+	result, err := mgr.Add(context.Background(), "example.com/foo", AddOptions{})
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if result.Stdout != "added" {
+		t.Errorf("expected the main command's result, got %+v", result)
+	}
+	runner.AssertCalled(t, "go", "mod", "tidy")
+	if len(mgr.Warnings()) != 1 {
+		t.Errorf("expected a warning for the failed optional step, got %v", mgr.Warnings())
+	}
+}
+
+func TestGenericManager_RequiresVersion(t *testing.T) {
+	def := &definitions.Definition{
+		Name:   "bun",
+		Binary: "bun",
+		Commands: map[string]definitions.Command{
+			"outdated": {
+				Base:            []string{"outdated"},
+				RequiresVersion: "1.1.0",
+			},
+			"install": {
+				Base: []string{"install"},
+			},
+		},
+		Capabilities: []string{"install", "outdated"},
+	}
+
+	tests := []struct {
+		name    string
+		version string
+		wantErr bool
+	}{
+		{"older", "1.0.30", true},
+		{"same", "1.1.0", false},
+		{"newer", "1.2.5", false},
+		{"unknown", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := NewMockRunner()
+			mgr := NewGenericManager(def, "/test/project", WithRunner(runner))
+			mgr.detectVersion = func(*definitions.Definition) (string, error) {
+				return tt.version, nil
+			}
+
+			_, err := mgr.Outdated(context.Background())
+			var verr ErrUnsupportedVersion
+			if tt.wantErr {
+				if !errors.As(err, &verr) {
+					t.Fatalf("expected ErrUnsupportedVersion, got %v", err)
+				}
+				if verr.Version != tt.version || verr.Required != "1.1.0" || verr.Nearest != "" {
+					t.Errorf("unexpected error fields: %+v", verr)
+				}
+				want := def.Name + " " + tt.version + " not supported (requires >= 1.1.0)"
+				if err.Error() != want {
+					t.Errorf("got %q, want %q", err.Error(), want)
+				}
+				if len(runner.Captured) != 0 {
+					t.Errorf("expected command not to run, got %v", runner.Captured)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Outdated failed: %v", err)
+			}
+		})
+	}
+
+	// Commands without requires_version never trigger detection
+	mgr := NewGenericManager(def, "/test/project", WithRunner(NewMockRunner()))
+	mgr.detectVersion = func(*definitions.Definition) (string, error) {
+		t.Fatal("version detection should not run")
+		return "", nil
+	}
+	if _, err := mgr.Install(context.Background(), InstallOptions{}); err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+}
+
+func TestGenericManager_List_Options(t *testing.T) {
+	def := &definitions.Definition{
+		Name:   "pnpm",
+		Binary: "pnpm",
+		Commands: map[string]definitions.Command{
+			"list": {
+				Base: []string{"list"},
+				Flags: map[string]definitions.Flag{
+					"json":  {Values: []definitions.FlagValue{{Literal: "--json"}}},
+					"depth": {Values: []definitions.FlagValue{{Literal: "--depth"}, {Field: "depth"}}},
+					"tree":  {Values: []definitions.FlagValue{{Literal: "--tree"}}},
+				},
+			},
+		},
+		Capabilities: []string{"list"},
+	}
+
+	tests := []struct {
+		name     string
+		opts     ListOptions
+		expected []string
+	}{
+		{"defaults", ListOptions{}, []string{"pnpm", "list"}},
+		{"json", ListOptions{JSON: true}, []string{"pnpm", "list", "--json"}},
+		{"depth", ListOptions{Depth: 2}, []string{"pnpm", "list", "--depth", "2"}},
+		{"tree", ListOptions{Tree: true}, []string{"pnpm", "list", "--tree"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := NewMockRunner()
+			mgr := NewGenericManager(def, "/test/project", WithRunner(runner))
+			if _, err := mgr.List(context.Background(), tt.opts); err != nil {
+				t.Fatalf("List failed: %v", err)
+			}
+			if !slicesEqual(runner.LastCaptured(), tt.expected) {
+				t.Errorf("got command %v, want %v", runner.LastCaptured(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestGenericManager_Exec(t *testing.T) {
+	runner := NewMockRunner()
+	mgr := NewGenericManager(embeddedDefinition(t, "bundler"), "/test/project", WithRunner(runner))
+
+	if _, err := mgr.Exec(context.Background(), "rspec spec/models --fail-fast"); err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	expected := []string{"bundle", "exec", "rspec", "spec/models", "--fail-fast"}
+	if !slicesEqual(runner.LastCaptured(), expected) {
+		t.Errorf("got command %v, want %v", runner.LastCaptured(), expected)
+	}
+	if !mgr.Supports(CapExec) {
+		t.Error("expected manager to support CapExec")
+	}
+
+	if _, err := mgr.Exec(context.Background(), "  "); err == nil {
+		t.Error("expected error for empty command")
+	}
+}
+
+func TestNewGenericManager(t *testing.T) {
+	def := embeddedDefinition(t, "npm")
+
+	mgr := NewGenericManager(def, "/test/project")
+	if _, ok := mgr.runner.(*ExecRunner); !ok {
+		t.Errorf("expected default ExecRunner, got %T", mgr.runner)
+	}
+	if _, ok := mgr.translator.Definition("npm"); !ok {
+		t.Error("expected default translator to know npm")
+	}
+
+	translator := NewTranslator()
+	runner := NewMockRunner()
+	mgr = NewGenericManager(def, "/test/project",
+		WithTranslator(translator),
+		WithRunner(runner),
+		WithDir("/other/project"),
+	)
+	if _, ok := translator.Definition("npm"); !ok {
+		t.Error("expected npm to be registered with the given translator")
+	}
+	if mgr.Dir() != "/other/project" {
+		t.Errorf("got dir %q, want %q", mgr.Dir(), "/other/project")
+	}
+
+	if _, err := mgr.Install(context.Background(), InstallOptions{}); err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+	if !slicesEqual(runner.LastCaptured(), []string{"npm", "install"}) {
+		t.Errorf("got command %v, want [npm install]", runner.LastCaptured())
+	}
+}
+
+func TestGenericManager_BrewfilePath(t *testing.T) {
+	runner := NewMockRunner()
+	mgr := NewGenericManager(embeddedDefinition(t, "brew"), "/test/project",
+		WithRunner(runner),
+		WithBrewfilePath("config/Brewfile"),
+	)
+
+	if _, err := mgr.Install(context.Background(), InstallOptions{NoUpgrade: true}); err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+	expected := []string{"brew", "bundle", "install", "--file", "config/Brewfile", "--no-upgrade"}
+	if !slicesEqual(runner.LastCaptured(), expected) {
+		t.Errorf("got command %v, want %v", runner.LastCaptured(), expected)
+	}
+}
+
+func TestGenericManager_Search(t *testing.T) {
+	tests := []struct {
+		manager  string
+		query    string
+		opts     SearchOptions
+		expected []string
+	}{
+		{"npm", "left-pad", SearchOptions{}, []string{"npm", "search", "left-pad"}},
+		{"npm", "left-pad", SearchOptions{JSON: true, Limit: 5}, []string{"npm", "search", "left-pad", "--json", "--searchlimit", "5"}},
+		{"gem", "rails", SearchOptions{}, []string{"gem", "search", "rails"}},
+		{"pip", "requests", SearchOptions{}, []string{"pip", "index", "versions", "requests"}},
+		{"snap", "vlc", SearchOptions{}, []string{"snap", "find", "vlc"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.manager, func(t *testing.T) {
+			runner := NewMockRunner()
+			mgr := NewGenericManager(embeddedDefinition(t, tt.manager), "/test/project", WithRunner(runner))
+
+			if _, err := mgr.Search(context.Background(), tt.query, tt.opts); err != nil {
+				t.Fatalf("Search failed: %v", err)
+			}
+			if !slicesEqual(runner.LastCaptured(), tt.expected) {
+				t.Errorf("got command %v, want %v", runner.LastCaptured(), tt.expected)
+			}
+			if !mgr.Supports(CapSearch) {
+				t.Error("expected manager to support CapSearch")
+			}
+		})
+	}
+
+	mgr := NewGenericManager(embeddedDefinition(t, "npm"), "/test/project", WithRunner(NewMockRunner()))
+	if _, err := mgr.Search(context.Background(), "", SearchOptions{}); err == nil {
+		t.Error("expected error for empty query")
+	}
+}
+
+func TestGenericManager_Run(t *testing.T) {
+	tests := []struct {
+		manager  string
+		args     []string
+		expected []string
+	}{
+		{"npm", nil, []string{"npm", "run", "test"}},
+		{"npm", []string{"--watch"}, []string{"npm", "run", "test", "--", "--watch"}},
+		{"deno", []string{"--filter", "unit"}, []string{"deno", "task", "test", "--filter", "unit"}},
+		{"mix", []string{"--cover"}, []string{"mix", "test", "--cover"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.manager, func(t *testing.T) {
+			runner := NewMockRunner()
+			mgr := NewGenericManager(embeddedDefinition(t, tt.manager), "/test/project", WithRunner(runner))
+
+			if _, err := mgr.Run(context.Background(), "test", tt.args...); err != nil {
+				t.Fatalf("Run failed: %v", err)
+			}
+			runner.AssertCalled(t, tt.expected...)
+			if !mgr.Supports(CapRun) {
+				t.Error("expected manager to support CapRun")
+			}
+		})
+	}
+
+	mgr := NewGenericManager(embeddedDefinition(t, "npm"), "/test/project", WithRunner(NewMockRunner()))
+	if _, err := mgr.Run(context.Background(), ""); err == nil {
+		t.Error("expected error for empty script")
+	}
+}
+
+func TestGenericManager_Warnings(t *testing.T) {
+	def := &definitions.Definition{
+		Name:   "bundler",
+		Binary: "bundle",
+		Commands: map[string]definitions.Command{
+			"add": {
+				Base: []string{"add"},
+				Args: map[string]definitions.Arg{
+					"package": {Position: 0, Required: true},
+				},
+				Flags: map[string]definitions.Flag{
+					"dev": {Values: []definitions.FlagValue{{Literal: "--group"}, {Literal: "development"}}},
+				},
+			},
+			"outdated": {
+				Base:            []string{"outdated"},
+				RequiresVersion: "2.0.0",
+				ExitCodes:       map[int]string{0: "success", 1: "outdated"},
+			},
+		},
+		Capabilities: []string{"add", "outdated"},
+	}
+
+	runner := NewMockRunner()
+	runner.Results = []*Result{{ExitCode: 0}, {ExitCode: 0}, {ExitCode: 7}}
+	mgr := NewGenericManager(def, "/test/project", WithRunner(runner))
+	mgr.detectVersion = func(*definitions.Definition) (string, error) {
+		return "", errors.New("not installed")
+	}
+
+	if _, err := mgr.Add(context.Background(), "rails", AddOptions{Dev: true, Peer: true, Exact: true}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	expected := []string{"bundle", "add", "rails", "--group", "development"}
+	if !slicesEqual(runner.LastCaptured(), expected) {
+		t.Errorf("got command %v, want %v", runner.LastCaptured(), expected)
+	}
+	want := []string{
+		"bundler add does not support the exact flag; it was ignored",
+		"bundler add does not support the peer flag; it was ignored",
+	}
+	if !slicesEqual(mgr.Warnings(), want) {
+		t.Fatalf("got warnings %v, want %v", mgr.Warnings(), want)
+	}
+
+	// Warnings accumulate across operations
+	if _, err := mgr.Outdated(context.Background()); err != nil {
+		t.Fatalf("Outdated failed: %v", err)
+	}
+	if _, err := mgr.Outdated(context.Background()); err != nil {
+		t.Fatalf("Outdated failed: %v", err)
+	}
+	want = append(want,
+		"could not determine bundler version; skipping requires_version checks",
+		"bundler outdated exited with code 7, which its definition doesn't describe",
+	)
+	if !slicesEqual(mgr.Warnings(), want) {
+		t.Fatalf("got warnings %v, want %v", mgr.Warnings(), want)
+	}
+
+	mgr.ClearWarnings()
+	if len(mgr.Warnings()) != 0 {
+		t.Errorf("expected no warnings after ClearWarnings, got %v", mgr.Warnings())
+	}
+}
+
+func TestGenericManager_Add_GroupOverridesDev(t *testing.T) {
+	def := &definitions.Definition{
+		Name:   "uv",
+		Binary: "uv",
+		Commands: map[string]definitions.Command{
+			"add": {
+				Base: []string{"add"},
+				Args: map[string]definitions.Arg{
+					"package": {Position: 0, Required: true},
+				},
+				Flags: map[string]definitions.Flag{
+					"dev":   {Values: []definitions.FlagValue{{Literal: "--dev"}}},
+					"group": {Values: []definitions.FlagValue{{Literal: "--group"}, {Field: "group"}}},
+				},
+			},
+		},
+		Capabilities: []string{"add"},
+	}
+
+	runner := NewMockRunner()
+	mgr := NewGenericManager(def, "/test/project", WithRunner(runner))
+	if _, err := mgr.Add(context.Background(), "pytest", AddOptions{Dev: true, Group: "test"}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	expected := []string{"uv", "add", "pytest", "--group", "test"}
+	if !slicesEqual(runner.LastCaptured(), expected) {
+		t.Errorf("got command %v, want %v", runner.LastCaptured(), expected)
+	}
+}
+
+type opRecorder struct {
+	ops []*PolicyOperation
+}
+
+func (r *opRecorder) OnPolicyResult(op *PolicyOperation, policy Policy, result *PolicyResult) {
+	r.ops = append(r.ops, op)
+}
+
+func TestGenericManager_PolicyRunnerGetsOperation(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/foo\n\ngo 1.19\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	def := &definitions.Definition{
+		Name:   "gomod",
+		Binary: "go",
+		Commands: map[string]definitions.Command{
+			"add": {
+				Base: []string{"get"},
+				Args: map[string]definitions.Arg{
+					"package": {Position: 0, Required: true},
+				},
+			},
+		},
+		Capabilities: []string{"add"},
+	}
+
+	mock := NewMockRunner()
+	recorder := &opRecorder{}
+	pr := NewPolicyRunner(mock,
+		WithPolicies(policies.GoVersionPolicy{MinVersion: "1.21"}),
+		WithPolicyHandler(recorder),
+	)
+
+	mgr := NewGenericManager(def, dir, WithRunner(pr))
+
+	_, err := mgr.Add(context.Background(), "github.com/pkg/errors", AddOptions{})
+	var violation *ErrPolicyViolation
+	if !errors.As(err, &violation) {
+		t.Fatalf("expected ErrPolicyViolation, got %v", err)
+	}
+
+	if len(recorder.ops) != 1 {
+		t.Fatalf("expected 1 policy result, got %d", len(recorder.ops))
+	}
+	op := recorder.ops[0]
+	if op.Manager != "gomod" || op.Operation != "add" {
+		t.Errorf("got manager %q operation %q, want gomod add", op.Manager, op.Operation)
+	}
+	if !slicesEqual(op.Packages, []string{"github.com/pkg/errors"}) {
+		t.Errorf("got packages %v", op.Packages)
+	}
+	if op.Args["go_version"] != "1.19" {
+		t.Errorf("got go_version %q, want %q", op.Args["go_version"], "1.19")
+	}
+	if len(mock.Captured) != 0 {
+		t.Errorf("expected no commands executed, got %d", len(mock.Captured))
+	}
+}
+
 func slicesEqual(a, b []string) bool {
 	if len(a) != len(b) {
 		return false
@@ -453,3 +1207,46 @@ func slicesEqual(a, b []string) bool {
 	}
 	return true
 }
+
+// staticRunner returns an empty successful result and is safe to share
+// between goroutines, unlike MockRunner which records every call.
+type staticRunner struct{}
+
+func (staticRunner) Run(ctx context.Context, dir string, args ...string) (*Result, error) {
+	return &Result{Command: args}, nil
+}
+
+// Run with -race to catch unsynchronised access to warnings.
+func TestGenericManager_WarningsConcurrent(t *testing.T) {
+	def := &definitions.Definition{
+		Name:   "bundler",
+		Binary: "bundle",
+		Commands: map[string]definitions.Command{
+			"add": {
+				Base: []string{"add"},
+				Args: map[string]definitions.Arg{
+					"package": {Position: 0, Required: true},
+				},
+			},
+		},
+	}
+	mgr := NewGenericManager(def, t.TempDir(), WithRunner(staticRunner{}))
+
+	const workers = 8
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := mgr.Add(context.Background(), "rails", AddOptions{Peer: true}); err != nil {
+				t.Errorf("Add failed: %v", err)
+			}
+			_ = mgr.Warnings()
+		}()
+	}
+	wg.Wait()
+
+	if got := len(mgr.Warnings()); got != workers {
+		t.Errorf("got %d warnings, want %d", got, workers)
+	}
+}
@@ -0,0 +1,95 @@
+package managers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/build"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ParseGoDirective returns the version from the go directive in go.mod
+// content (e.g. "1.21" from "go 1.21"), or "" if there isn't one.
+func ParseGoDirective(data []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "go" {
+			return fields[1]
+		}
+	}
+	return ""
+}
+
+// readGoDirective reads go.mod in dir and returns its go directive.
+// A missing or unreadable go.mod yields "".
+func readGoDirective(dir string) string {
+	data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	return ParseGoDirective(data)
+}
+
+// extractGoModuleCache builds a module's location in the module cache from
+// the Path and Version fields of `go list -m -json` output. Used when Dir is
+// missing, which happens when the module hasn't been downloaded yet.
+func extractGoModuleCache(output string) (string, error) {
+	var mod struct {
+		Path    string
+		Version string
+	}
+	if err := json.NewDecoder(strings.NewReader(output)).Decode(&mod); err != nil {
+		return "", fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	if mod.Path == "" || mod.Version == "" {
+		return "", fmt.Errorf("module Path and Version are required to locate the module cache")
+	}
+
+	return filepath.Join(goModCacheDir(), filepath.FromSlash(escapeModulePath(mod.Path))+"@"+escapeModulePath(mod.Version)), nil
+}
+
+// goModCacheDir returns GOMODCACHE, defaulting to GOPATH/pkg/mod like the go command.
+func goModCacheDir() string {
+	if dir := os.Getenv("GOMODCACHE"); dir != "" {
+		return dir
+	}
+	gopath := filepath.SplitList(build.Default.GOPATH)
+	if len(gopath) == 0 {
+		return ""
+	}
+	return filepath.Join(gopath[0], "pkg", "mod")
+}
+
+// escapeModulePath applies the module cache's case encoding, where each
+// upper-case letter becomes "!" followed by its lower-case form.
+func escapeModulePath(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if 'A' <= r && r <= 'Z' {
+			b.WriteByte('!')
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// GoToolchainVersion reports the version of the go binary on PATH,
+// e.g. "1.22.4".
+func GoToolchainVersion(ctx context.Context) (string, error) {
+	out, err := exec.CommandContext(ctx, "go", "env", "GOVERSION").Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimPrefix(strings.TrimSpace(string(out)), "go"), nil
+}
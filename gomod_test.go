@@ -0,0 +1,25 @@
+package managers
+
+import "testing"
+
+func TestParseGoDirective(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"simple", "module example.com/foo\n\ngo 1.21\n", "1.21"},
+		{"patch version", "module example.com/foo\ngo 1.22.4\ntoolchain go1.23.0\n", "1.22.4"},
+		{"trailing comment", "module example.com/foo\ngo 1.20 // minimum\n", "1.20"},
+		{"commented out", "module example.com/foo\n// go 1.19\n", ""},
+		{"missing", "module example.com/foo\n", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseGoDirective([]byte(tt.data)); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+// Package version compares the loosely formatted version strings package
+// managers report.
+package version
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Compare compares two dotted versions such as "1.21", "v1.1.0" or
+// "go1.22rc1", returning -1, 0 or 1. Missing components count as zero and
+// pre-release suffixes are ignored.
+func Compare(a, b string) int {
+	pa := parts(a)
+	pb := parts(b)
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func parts(v string) []int {
+	v = strings.TrimSpace(v)
+	v = strings.TrimPrefix(v, "go")
+	v = strings.TrimPrefix(v, "v")
+	var parts []int
+	for _, s := range strings.Split(v, ".") {
+		end := 0
+		for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+			end++
+		}
+		n, err := strconv.Atoi(s[:end])
+		if err != nil {
+			break
+		}
+		parts = append(parts, n)
+		if end < len(s) {
+			break
+		}
+	}
+	return parts
+}
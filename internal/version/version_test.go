@@ -0,0 +1,26 @@
+package version
+
+import "testing"
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.21", "1.21", 0},
+		{"1.21", "1.21.0", 0},
+		{"1.21.3", "1.21", 1},
+		{"1.20", "1.21", -1},
+		{"go1.22.1", "1.22", 1},
+		{"1.22rc1", "1.22", 0},
+		{"1.9", "1.10", -1},
+		{"v1.1.0", "1.1", 0},
+		{"1.0.30", "1.1", -1},
+	}
+
+	for _, tt := range tests {
+		if got := Compare(tt.a, tt.b); got != tt.want {
+			t.Errorf("Compare(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
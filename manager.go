@@ -11,29 +11,63 @@ type Manager interface {
 
 	Install(ctx context.Context, opts InstallOptions) (*Result, error)
 	Add(ctx context.Context, pkg string, opts AddOptions) (*Result, error)
-	Remove(ctx context.Context, pkg string) (*Result, error)
-	List(ctx context.Context) (*Result, error)
+	Remove(ctx context.Context, pkg string, opts RemoveOptions) (*Result, error)
+	List(ctx context.Context, opts ListOptions) (*Result, error)
 	Outdated(ctx context.Context) (*Result, error)
-	Update(ctx context.Context, pkg string) (*Result, error)
+	Update(ctx context.Context, pkg string, opts UpdateOptions) (*Result, error)
 	Path(ctx context.Context, pkg string) (*PathResult, error)
 	Vendor(ctx context.Context) (*Result, error)
 	Resolve(ctx context.Context) (*Result, error)
+	Clean(ctx context.Context) (*Result, error)
+	Run(ctx context.Context, script string, args ...string) (*Result, error)
+	Search(ctx context.Context, query string, opts SearchOptions) (*Result, error)
 
 	Supports(cap Capability) bool
 	Capabilities() []Capability
 }
 
 type InstallOptions struct {
-	Frozen     bool
-	Clean      bool
-	Production bool
+	Frozen           bool
+	Clean            bool
+	Production       bool
+	Workspaces       bool     // install every workspace member, not just the root
+	NoUpgrade        bool     // leave already-installed packages at their current version
+	IgnoreScripts    bool     // don't run package lifecycle scripts such as postinstall
+	BuildMissing     bool     // build packages from source when no prebuilt binary exists
+	IncludeGroups    []string // optional dependency groups to install as well (poetry --with)
+	IncludePlatforms []string // extra platforms to install optional binaries for, as os=, cpu= or libc= (e.g. "os=linux", "cpu=x64"); other keys are rejected
+}
+
+type ListOptions struct {
+	JSON         bool // request machine-readable output where supported
+	Depth        int  // dependency tree depth; 0 leaves the manager's default
+	OutdatedOnly bool // only list packages with newer versions available
+	Tree         bool // show transitive dependencies as a tree (poetry show --tree)
+}
+
+type UpdateOptions struct {
+	DryRun bool // report what would change without modifying anything
+}
+
+type RemoveOptions struct {
+	Zap bool // also delete the package's settings and caches (brew uninstall --zap)
+}
+
+type SearchOptions struct {
+	JSON  bool // request machine-readable output where supported
+	Limit int  // maximum number of results; 0 leaves the manager's default
 }
 
 type AddOptions struct {
-	Dev       bool
-	Optional  bool
-	Exact     bool
-	Workspace string
+	Dev           bool
+	Optional      bool
+	Peer          bool
+	Exact         bool
+	Workspace     string
+	Group         string // named dependency group (e.g. PEP 735); takes precedence over Dev
+	UserInstall   bool   // install into the user's home directory instead of system-wide
+	Features      string // comma-separated features to enable (e.g. cargo --features)
+	IgnoreScripts bool   // don't run package lifecycle scripts such as postinstall
 }
 
 type Result struct {
@@ -51,8 +85,9 @@ func (r *Result) Success() bool {
 }
 
 type PathResult struct {
-	Path   string // extracted path to the package
-	Result *Result // underlying command result
+	Path            string  // extracted path to the package
+	Result          *Result // underlying command result
+	ExtractionError error   // set when the command ran but the path couldn't be extracted from its output
 }
 
 type ExecContext int
@@ -84,6 +119,11 @@ const (
 	CapPath
 	CapVendor
 	CapResolve
+	CapClean
+	CapDevelop
+	CapExec
+	CapRun
+	CapSearch
 )
 
 var capabilityNames = map[Capability]string{
@@ -105,6 +145,11 @@ var capabilityNames = map[Capability]string{
 	CapPath:          "path",
 	CapVendor:        "vendor",
 	CapResolve:       "resolve",
+	CapClean:         "clean",
+	CapDevelop:       "develop",
+	CapExec:          "exec",
+	CapRun:           "run",
+	CapSearch:        "search",
 }
 
 func (c Capability) String() string {
@@ -0,0 +1,336 @@
+package policies
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/git-pkgs/managers/internal/version"
+)
+
+// AllowAllPolicy is a no-op policy that allows all operations.
+// Useful as a placeholder or for testing.
+type AllowAllPolicy struct{}
+
+func (AllowAllPolicy) Name() string { return "allow-all" }
+
+func (AllowAllPolicy) Scope() []string { return []string{} }
+
+func (AllowAllPolicy) Check(ctx context.Context, op *Operation) (*Result, error) {
+	return &Result{Allowed: true}, nil
+}
+
+// DenyAllPolicy is a policy that denies all operations.
+// Useful for testing or as a circuit breaker.
+type DenyAllPolicy struct {
+	Reason string
+}
+
+func (DenyAllPolicy) Name() string { return "deny-all" }
+
+func (DenyAllPolicy) Scope() []string { return []string{} }
+
+func (p DenyAllPolicy) Check(ctx context.Context, op *Operation) (*Result, error) {
+	reason := p.Reason
+	if reason == "" {
+		reason = "all operations denied by policy"
+	}
+	return &Result{Allowed: false, Reason: reason}, nil
+}
+
+// PackageBlocklistPolicy denies operations on specific packages.
+type PackageBlocklistPolicy struct {
+	Blocked map[string]string // package name -> reason
+}
+
+func (PackageBlocklistPolicy) Name() string { return "package-blocklist" }
+
+func (PackageBlocklistPolicy) Scope() []string { return []string{} }
+
+func (p PackageBlocklistPolicy) Check(ctx context.Context, op *Operation) (*Result, error) {
+	for _, pkg := range op.Packages {
+		if reason, blocked := p.Blocked[pkg]; blocked {
+			return &Result{
+				Allowed: false,
+				Reason:  reason,
+				Metadata: map[string]any{
+					"blocked_package": pkg,
+				},
+			}, nil
+		}
+	}
+	return &Result{Allowed: true}, nil
+}
+
+// GoVersionPolicy checks Go versions for gomod operations.
+// The go directive from go.mod is read from op.Args["go_version"], which
+// managers.GenericManager fills in for gomod projects. Operations without it
+// are allowed.
+type GoVersionPolicy struct {
+	// MinVersion is the lowest go directive a module may declare.
+	// Empty skips this check.
+	MinVersion string
+
+	// InstalledVersion reports the local Go toolchain version. When set, the
+	// operation is denied if the toolchain is older than the go directive.
+	// managers.GoToolchainVersion is a suitable implementation.
+	InstalledVersion func(ctx context.Context) (string, error)
+}
+
+func (GoVersionPolicy) Name() string { return "go-version" }
+
+func (GoVersionPolicy) Scope() []string { return []string{} }
+
+func (p GoVersionPolicy) Check(ctx context.Context, op *Operation) (*Result, error) {
+	required := op.Args["go_version"]
+	if required == "" {
+		return &Result{Allowed: true}, nil
+	}
+
+	if p.MinVersion != "" && version.Compare(required, p.MinVersion) < 0 {
+		return &Result{
+			Allowed: false,
+			Reason:  fmt.Sprintf("go.mod declares go %s, below minimum %s", required, p.MinVersion),
+			Metadata: map[string]any{
+				"go_version":  required,
+				"min_version": p.MinVersion,
+			},
+		}, nil
+	}
+
+	if p.InstalledVersion != nil {
+		installed, err := p.InstalledVersion(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if version.Compare(installed, required) < 0 {
+			return &Result{
+				Allowed: false,
+				Reason:  fmt.Sprintf("installed go %s is older than go %s required by go.mod", installed, required),
+				Metadata: map[string]any{
+					"go_version":        required,
+					"installed_version": installed,
+				},
+			}, nil
+		}
+	}
+
+	return &Result{Allowed: true}, nil
+}
+
+// ApprovalPolicy asks a human to confirm destructive operations before they run.
+// Removals and operations that pin a version (op.Args["version"] set) go
+// through Prompt; everything else is allowed without asking.
+type ApprovalPolicy struct {
+	// Prompt asks for confirmation and reports whether the operation may proceed.
+	// A nil Prompt denies every operation that needs approval.
+	Prompt func(op *Operation) (bool, error)
+}
+
+func (ApprovalPolicy) Name() string { return "approval" }
+
+func (ApprovalPolicy) Scope() []string { return []string{} }
+
+func (p ApprovalPolicy) Check(ctx context.Context, op *Operation) (*Result, error) {
+	if op.Operation != "remove" && op.Args["version"] == "" {
+		return &Result{Allowed: true}, nil
+	}
+
+	if p.Prompt == nil {
+		return &Result{Allowed: false, Reason: "operation requires approval but no prompt is configured"}, nil
+	}
+
+	approved, err := p.Prompt(op)
+	if err != nil {
+		return nil, err
+	}
+	if !approved {
+		return &Result{Allowed: false, Reason: "operation not approved"}, nil
+	}
+	return &Result{Allowed: true, Reason: "approved"}, nil
+}
+
+// PublicRegistryPolicy denies scoped packages (such as @acme/utils) that
+// aren't known to exist on a public registry. A private-only scope can be
+// squatted on the public registry, so installing from it by name risks
+// pulling an attacker's package. Unscoped packages are always allowed.
+type PublicRegistryPolicy struct {
+	// AllowedRegistries lists package name prefixes known to be published
+	// publicly, such as "@types/" or "@babel/".
+	AllowedRegistries []string
+
+	// Scopes records whether each "@scope" is public (true) or private-only
+	// (false). It takes precedence over AllowedRegistries. Scopes found in
+	// neither are denied.
+	Scopes map[string]bool
+}
+
+func (PublicRegistryPolicy) Name() string { return "public-registry" }
+
+func (PublicRegistryPolicy) Scope() []string { return []string{} }
+
+func (p PublicRegistryPolicy) Check(ctx context.Context, op *Operation) (*Result, error) {
+	for _, pkg := range op.Packages {
+		if !strings.HasPrefix(pkg, "@") {
+			continue
+		}
+		scope, _, _ := strings.Cut(pkg, "/")
+
+		if public, known := p.Scopes[scope]; known {
+			if public {
+				continue
+			}
+			return &Result{
+				Allowed: false,
+				Reason:  fmt.Sprintf("%s is in private-only scope %s", pkg, scope),
+				Metadata: map[string]any{
+					"package": pkg,
+					"scope":   scope,
+				},
+			}, nil
+		}
+
+		allowed := false
+		for _, prefix := range p.AllowedRegistries {
+			if strings.HasPrefix(pkg, prefix) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return &Result{
+				Allowed: false,
+				Reason:  fmt.Sprintf("scope %s of %s is not known to be public", scope, pkg),
+				Metadata: map[string]any{
+					"package": pkg,
+					"scope":   scope,
+				},
+			}, nil
+		}
+	}
+	return &Result{Allowed: true}, nil
+}
+
+// AllowlistPolicy denies operations on any package not listed in Allowed.
+// Operations that don't name packages, such as install, are allowed.
+type AllowlistPolicy struct {
+	Allowed []string
+}
+
+func (AllowlistPolicy) Name() string { return "allowlist" }
+
+func (AllowlistPolicy) Scope() []string { return []string{} }
+
+func (p AllowlistPolicy) Check(ctx context.Context, op *Operation) (*Result, error) {
+	for _, pkg := range op.Packages {
+		if !slices.Contains(p.Allowed, pkg) {
+			return &Result{
+				Allowed: false,
+				Reason:  fmt.Sprintf("%s is not on the allowlist", pkg),
+				Metadata: map[string]any{
+					"package": pkg,
+				},
+			}, nil
+		}
+	}
+	return &Result{Allowed: true}, nil
+}
+
+// VersionConstraintPolicy denies pinning a package to a version outside its
+// constraint. Constraints are comma-separated comparisons such as
+// ">=1.2.0, <2.0.0", using =, !=, <, <=, > or >=. Operations that don't pin
+// a version (op.Args["version"] empty) and packages without a constraint
+// are allowed.
+type VersionConstraintPolicy struct {
+	Constraints map[string]string // package name -> constraint
+}
+
+func (VersionConstraintPolicy) Name() string { return "version-constraint" }
+
+func (VersionConstraintPolicy) Scope() []string { return []string{} }
+
+func (p VersionConstraintPolicy) Check(ctx context.Context, op *Operation) (*Result, error) {
+	requested := op.Args["version"]
+	if requested == "" {
+		return &Result{Allowed: true}, nil
+	}
+
+	for _, pkg := range op.Packages {
+		constraint, ok := p.Constraints[pkg]
+		if !ok {
+			continue
+		}
+		satisfied, err := satisfies(requested, constraint)
+		if err != nil {
+			return nil, fmt.Errorf("constraint for %s: %w", pkg, err)
+		}
+		if !satisfied {
+			return &Result{
+				Allowed: false,
+				Reason:  fmt.Sprintf("%s %s does not satisfy %s", pkg, requested, constraint),
+				Metadata: map[string]any{
+					"package":    pkg,
+					"version":    requested,
+					"constraint": constraint,
+				},
+			}, nil
+		}
+	}
+	return &Result{Allowed: true}, nil
+}
+
+// satisfies reports whether v meets every comparison in constraint.
+func satisfies(v, constraint string) (bool, error) {
+	for _, part := range strings.Split(constraint, ",") {
+		part = strings.TrimSpace(part)
+		op := part[:len(part)-len(strings.TrimLeft(part, "=!<>"))]
+		want := strings.TrimSpace(part[len(op):])
+		if digits := strings.TrimPrefix(want, "v"); digits == "" || digits[0] < '0' || digits[0] > '9' {
+			return false, fmt.Errorf("invalid constraint %q", part)
+		}
+
+		cmp := version.Compare(v, want)
+		var ok bool
+		switch op {
+		case "", "=", "==":
+			ok = cmp == 0
+		case "!=":
+			ok = cmp != 0
+		case "<":
+			ok = cmp < 0
+		case "<=":
+			ok = cmp <= 0
+		case ">":
+			ok = cmp > 0
+		case ">=":
+			ok = cmp >= 0
+		default:
+			return false, fmt.Errorf("invalid operator %q in constraint %q", op, part)
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// AuditPolicy passes every operation to Record before it runs, for audit
+// logging. It never denies an operation itself, but an error from Record
+// stops the operation so nothing runs unrecorded.
+type AuditPolicy struct {
+	Record func(ctx context.Context, op *Operation) error
+}
+
+func (AuditPolicy) Name() string { return "audit" }
+
+func (AuditPolicy) Scope() []string { return []string{} }
+
+func (p AuditPolicy) Check(ctx context.Context, op *Operation) (*Result, error) {
+	if p.Record != nil {
+		if err := p.Record(ctx, op); err != nil {
+			return nil, err
+		}
+	}
+	return &Result{Allowed: true, Reason: "recorded"}, nil
+}
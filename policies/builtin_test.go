@@ -0,0 +1,263 @@
+package policies
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestPackageBlocklistPolicy(t *testing.T) {
+	policy := PackageBlocklistPolicy{
+		Blocked: map[string]string{
+			"evil-package":   "known malware",
+			"deprecated-lib": "no longer maintained",
+		},
+	}
+
+	tests := []struct {
+		name     string
+		packages []string
+		allowed  bool
+	}{
+		{"allowed package", []string{"lodash"}, true},
+		{"blocked package", []string{"evil-package"}, false},
+		{"mixed packages", []string{"lodash", "deprecated-lib"}, false},
+		{"empty packages", []string{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op := &Operation{Packages: tt.packages}
+			result, err := policy.Check(context.Background(), op)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Allowed != tt.allowed {
+				t.Errorf("got allowed=%v, want %v", result.Allowed, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestPublicRegistryPolicy(t *testing.T) {
+	policy := PublicRegistryPolicy{
+		AllowedRegistries: []string{"@types/", "@babel/"},
+		Scopes: map[string]bool{
+			"@acme":   false,
+			"@vercel": true,
+			"@babel":  false, // explicit entry overrides the prefix
+		},
+	}
+
+	tests := []struct {
+		name     string
+		packages []string
+		allowed  bool
+	}{
+		{"unscoped package", []string{"lodash"}, true},
+		{"allowed prefix", []string{"@types/node"}, true},
+		{"public scope", []string{"@vercel/ncc"}, true},
+		{"private scope", []string{"@acme/utils"}, false},
+		{"unknown scope", []string{"@someone/pkg"}, false},
+		{"scope overrides prefix", []string{"@babel/core"}, false},
+		{"mixed packages", []string{"lodash", "@acme/utils"}, false},
+		{"empty packages", []string{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op := &Operation{Packages: tt.packages}
+			result, err := policy.Check(context.Background(), op)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Allowed != tt.allowed {
+				t.Errorf("got allowed=%v, want %v (%s)", result.Allowed, tt.allowed, result.Reason)
+			}
+		})
+	}
+}
+
+func TestGoVersionPolicy(t *testing.T) {
+	installed := func(v string) func(context.Context) (string, error) {
+		return func(context.Context) (string, error) { return v, nil }
+	}
+
+	tests := []struct {
+		name    string
+		policy  GoVersionPolicy
+		args    map[string]string
+		allowed bool
+	}{
+		{"no go directive", GoVersionPolicy{MinVersion: "1.21"}, nil, true},
+		{"directive meets minimum", GoVersionPolicy{MinVersion: "1.21"}, map[string]string{"go_version": "1.22"}, true},
+		{"directive below minimum", GoVersionPolicy{MinVersion: "1.21"}, map[string]string{"go_version": "1.19"}, false},
+		{"toolchain new enough", GoVersionPolicy{InstalledVersion: installed("1.22.4")}, map[string]string{"go_version": "1.22"}, true},
+		{"toolchain too old", GoVersionPolicy{InstalledVersion: installed("1.20.1")}, map[string]string{"go_version": "1.21"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op := &Operation{Manager: "gomod", Args: tt.args}
+			result, err := tt.policy.Check(context.Background(), op)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Allowed != tt.allowed {
+				t.Errorf("got allowed=%v, want %v (reason: %s)", result.Allowed, tt.allowed, result.Reason)
+			}
+		})
+	}
+}
+
+func TestGoVersionPolicyInstalledVersionError(t *testing.T) {
+	policy := GoVersionPolicy{
+		InstalledVersion: func(context.Context) (string, error) {
+			return "", errors.New("go not found")
+		},
+	}
+	op := &Operation{Args: map[string]string{"go_version": "1.21"}}
+	if _, err := policy.Check(context.Background(), op); err == nil {
+		t.Error("expected error, got nil")
+	}
+}
+
+func TestApprovalPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		op       *Operation
+		approve  bool
+		allowed  bool
+		prompted bool
+	}{
+		{"install skips prompt", &Operation{Operation: "install"}, false, true, false},
+		{"remove approved", &Operation{Operation: "remove"}, true, true, true},
+		{"remove rejected", &Operation{Operation: "remove"}, false, false, true},
+		{"version change rejected", &Operation{Operation: "add", Args: map[string]string{"version": "2.0.0"}}, false, false, true},
+		{"add without version skips prompt", &Operation{Operation: "add", Args: map[string]string{"package": "lodash"}}, false, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prompted := false
+			policy := ApprovalPolicy{Prompt: func(op *Operation) (bool, error) {
+				prompted = true
+				return tt.approve, nil
+			}}
+			result, err := policy.Check(context.Background(), tt.op)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Allowed != tt.allowed {
+				t.Errorf("got allowed=%v, want %v", result.Allowed, tt.allowed)
+			}
+			if prompted != tt.prompted {
+				t.Errorf("got prompted=%v, want %v", prompted, tt.prompted)
+			}
+		})
+	}
+}
+
+func TestAllowlistPolicy(t *testing.T) {
+	policy := AllowlistPolicy{Allowed: []string{"lodash", "react"}}
+
+	tests := []struct {
+		name     string
+		packages []string
+		allowed  bool
+	}{
+		{"listed package", []string{"lodash"}, true},
+		{"unlisted package", []string{"left-pad"}, false},
+		{"mixed packages", []string{"react", "left-pad"}, false},
+		{"no packages", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := policy.Check(context.Background(), &Operation{Packages: tt.packages})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Allowed != tt.allowed {
+				t.Errorf("got allowed=%v, want %v (%s)", result.Allowed, tt.allowed, result.Reason)
+			}
+		})
+	}
+}
+
+func TestVersionConstraintPolicy(t *testing.T) {
+	policy := VersionConstraintPolicy{
+		Constraints: map[string]string{
+			"lodash": ">=4.17.21, <5",
+			"react":  "!=18.0.0",
+			"left":   "1.3.0",
+		},
+	}
+
+	tests := []struct {
+		name    string
+		pkg     string
+		version string
+		allowed bool
+	}{
+		{"no version pinned", "lodash", "", true},
+		{"within range", "lodash", "4.17.21", true},
+		{"below range", "lodash", "4.17.20", false},
+		{"above range", "lodash", "5.0.0", false},
+		{"excluded version", "react", "18.0.0", false},
+		{"not excluded", "react", "18.2.0", true},
+		{"exact match", "left", "1.3", true},
+		{"exact mismatch", "left", "1.4.0", false},
+		{"unconstrained package", "express", "1.0.0", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op := &Operation{
+				Packages: []string{tt.pkg},
+				Args:     map[string]string{"package": tt.pkg, "version": tt.version},
+			}
+			result, err := policy.Check(context.Background(), op)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Allowed != tt.allowed {
+				t.Errorf("got allowed=%v, want %v (%s)", result.Allowed, tt.allowed, result.Reason)
+			}
+		})
+	}
+}
+
+func TestVersionConstraintPolicyInvalidConstraint(t *testing.T) {
+	policy := VersionConstraintPolicy{Constraints: map[string]string{"lodash": "~>4"}}
+	op := &Operation{Packages: []string{"lodash"}, Args: map[string]string{"version": "4.0.0"}}
+	if _, err := policy.Check(context.Background(), op); err == nil {
+		t.Error("expected error for unsupported operator")
+	}
+}
+
+func TestAuditPolicy(t *testing.T) {
+	var recorded []string
+	policy := AuditPolicy{Record: func(ctx context.Context, op *Operation) error {
+		recorded = append(recorded, op.Manager+" "+op.Operation)
+		return nil
+	}}
+
+	result, err := policy.Check(context.Background(), &Operation{Manager: "npm", Operation: "add"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Allowed {
+		t.Error("expected audit policy to allow the operation")
+	}
+	if len(recorded) != 1 || recorded[0] != "npm add" {
+		t.Errorf("got recorded %v, want [npm add]", recorded)
+	}
+
+	failing := AuditPolicy{Record: func(context.Context, *Operation) error {
+		return errors.New("log unavailable")
+	}}
+	if _, err := failing.Check(context.Background(), &Operation{}); err == nil {
+		t.Error("expected record error to be returned")
+	}
+}
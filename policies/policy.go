@@ -0,0 +1,91 @@
+// Package policies defines the Policy interface checked before package
+// operations run, along with ready-made policies. It has no dependency on
+// the translator or detector, so policies can be written and shared without
+// importing them.
+package policies
+
+import (
+	"context"
+	"maps"
+	"slices"
+)
+
+// Policy defines an interface for checks that run before package operations.
+// Policies can inspect the operation details and either allow or deny execution.
+type Policy interface {
+	// Name returns a unique identifier for this policy.
+	Name() string
+
+	// Scope returns the operations this policy applies to (e.g. "add", "update").
+	// An empty scope means the policy applies to every operation.
+	Scope() []string
+
+	// Check evaluates the policy against the given operation.
+	// Returns a Result indicating whether the operation should proceed.
+	Check(ctx context.Context, op *Operation) (*Result, error)
+}
+
+// Operation contains details about the operation being checked.
+type Operation struct {
+	// Manager is the package manager name (e.g., "npm", "bundler").
+	Manager string
+
+	// Operation is the command being run (e.g., "add", "install", "update").
+	Operation string
+
+	// Packages is the list of packages being operated on.
+	// Empty for operations like "install" that don't target specific packages.
+	Packages []string
+
+	// Args contains the raw arguments passed to the command.
+	Args map[string]string
+
+	// Flags contains the flags passed to the command.
+	Flags map[string]any
+
+	// WorkingDir is the directory where the operation will run.
+	WorkingDir string
+
+	// ManifestFile is the path of the lockfile or manifest the manager was
+	// detected from. Empty when the manager was chosen explicitly.
+	ManifestFile string
+
+	// Command is the fully constructed command that will be executed.
+	Command []string
+}
+
+// Clone returns a copy of op that shares no slices or maps with it, so a
+// policy can modify what it is given without affecting the policies after
+// it or the command that runs. List flag values are copied too; other flag
+// values are copied as they are.
+func (op *Operation) Clone() *Operation {
+	c := *op
+	c.Packages = slices.Clone(op.Packages)
+	c.Args = maps.Clone(op.Args)
+	c.Command = slices.Clone(op.Command)
+	if op.Flags != nil {
+		c.Flags = make(map[string]any, len(op.Flags))
+		for name, val := range op.Flags {
+			if list, ok := val.([]string); ok {
+				val = slices.Clone(list)
+			}
+			c.Flags[name] = val
+		}
+	}
+	return &c
+}
+
+// Result contains the outcome of a policy check.
+type Result struct {
+	// Allowed indicates whether the operation should proceed.
+	Allowed bool
+
+	// Reason explains why the operation was allowed or denied.
+	Reason string
+
+	// Warnings contains non-blocking issues that should be reported.
+	Warnings []string
+
+	// Metadata contains policy-specific data for programmatic access.
+	Metadata map[string]any
+}
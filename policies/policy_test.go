@@ -0,0 +1,35 @@
+package policies
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOperationClone(t *testing.T) {
+	op := &Operation{
+		Manager:   "npm",
+		Operation: "add",
+		Packages:  []string{"lodash"},
+		Args:      map[string]string{"package": "lodash"},
+		Flags:     map[string]any{"dev": true, "include_groups": []string{"docs"}},
+		Command:   []string{"npm", "install", "lodash"},
+	}
+
+	c := op.Clone()
+	if !reflect.DeepEqual(c, op) {
+		t.Fatalf("clone %+v differs from %+v", c, op)
+	}
+
+	c.Packages[0] = "changed"
+	c.Args["package"] = "changed"
+	c.Flags["dev"] = false
+	c.Flags["include_groups"].([]string)[0] = "changed"
+	c.Command[0] = "changed"
+
+	if op.Packages[0] != "lodash" || op.Args["package"] != "lodash" || op.Command[0] != "npm" {
+		t.Errorf("changing the clone changed the original: %+v", op)
+	}
+	if op.Flags["dev"] != true || op.Flags["include_groups"].([]string)[0] != "docs" {
+		t.Errorf("changing the clone's flags changed the original: %v", op.Flags)
+	}
+}
@@ -2,57 +2,28 @@ package managers
 
 import (
 	"context"
+	"slices"
+
+	"github.com/git-pkgs/managers/policies"
 )
 
 // Policy defines an interface for checks that run before package operations.
-// Policies can inspect the operation details and either allow or deny execution.
-type Policy interface {
-	// Name returns a unique identifier for this policy.
-	Name() string
-
-	// Check evaluates the policy against the given operation.
-	// Returns a PolicyResult indicating whether the operation should proceed.
-	Check(ctx context.Context, op *PolicyOperation) (*PolicyResult, error)
-}
+// Ready-made policies live in the policies package.
+type Policy = policies.Policy
 
 // PolicyOperation contains details about the operation being checked.
-type PolicyOperation struct {
-	// Manager is the package manager name (e.g., "npm", "bundler").
-	Manager string
-
-	// Operation is the command being run (e.g., "add", "install", "update").
-	Operation string
-
-	// Packages is the list of packages being operated on.
-	// Empty for operations like "install" that don't target specific packages.
-	Packages []string
-
-	// Args contains the raw arguments passed to the command.
-	Args map[string]string
-
-	// Flags contains the flags passed to the command.
-	Flags map[string]any
-
-	// WorkingDir is the directory where the operation will run.
-	WorkingDir string
-
-	// Command is the fully constructed command that will be executed.
-	Command []string
-}
+type PolicyOperation = policies.Operation
 
 // PolicyResult contains the outcome of a policy check.
-type PolicyResult struct {
-	// Allowed indicates whether the operation should proceed.
-	Allowed bool
-
-	// Reason explains why the operation was allowed or denied.
-	Reason string
+type PolicyResult = policies.Result
 
-	// Warnings contains non-blocking issues that should be reported.
-	Warnings []string
-
-	// Metadata contains policy-specific data for programmatic access.
-	Metadata map[string]any
+// policyApplies reports whether a policy's scope covers the operation.
+func policyApplies(p Policy, operation string) bool {
+	scope := p.Scope()
+	if len(scope) == 0 {
+		return true
+	}
+	return slices.Contains(scope, operation)
 }
 
 // PolicyMode determines how policy violations are handled.
@@ -138,6 +109,8 @@ func (pr *PolicyRunner) AddPolicy(p Policy) {
 }
 
 // Run executes the command after checking all registered policies.
+// Without operation context the operation is taken to be the first argument
+// after the binary, which is what policy scopes are matched against.
 func (pr *PolicyRunner) Run(ctx context.Context, dir string, args ...string) (*Result, error) {
 	if pr.mode == PolicyDisabled {
 		return pr.inner.Run(ctx, dir, args...)
@@ -159,7 +132,13 @@ func (pr *PolicyRunner) Run(ctx context.Context, dir string, args ...string) (*R
 	}
 
 	for _, policy := range pr.policies {
-		result, err := policy.Check(ctx, op)
+		if !policyApplies(policy, op.Operation) {
+			continue
+		}
+
+		// Each policy gets its own copy so one can't change what the
+		// next sees
+		result, err := policy.Check(ctx, op.Clone())
 		if err != nil {
 			return nil, &ErrPolicyCheck{Policy: policy.Name(), Err: err}
 		}
@@ -184,11 +163,15 @@ func (pr *PolicyRunner) Run(ctx context.Context, dir string, args ...string) (*R
 // Use this when you have more information about the operation than just the command.
 func (pr *PolicyRunner) RunWithContext(ctx context.Context, op *PolicyOperation) (*Result, error) {
 	if pr.mode == PolicyDisabled {
-		return pr.inner.Run(ctx, op.WorkingDir, op.Command...)
+		return pr.runInner(ctx, op)
 	}
 
 	for _, policy := range pr.policies {
-		result, err := policy.Check(ctx, op)
+		if !policyApplies(policy, op.Operation) {
+			continue
+		}
+
+		result, err := policy.Check(ctx, op.Clone())
 		if err != nil {
 			return nil, &ErrPolicyCheck{Policy: policy.Name(), Err: err}
 		}
@@ -206,53 +189,15 @@ func (pr *PolicyRunner) RunWithContext(ctx context.Context, op *PolicyOperation)
 		}
 	}
 
-	return pr.inner.Run(ctx, op.WorkingDir, op.Command...)
-}
-
-// AllowAllPolicy is a no-op policy that allows all operations.
-// Useful as a placeholder or for testing.
-type AllowAllPolicy struct{}
-
-func (AllowAllPolicy) Name() string { return "allow-all" }
-
-func (AllowAllPolicy) Check(ctx context.Context, op *PolicyOperation) (*PolicyResult, error) {
-	return &PolicyResult{Allowed: true}, nil
-}
-
-// DenyAllPolicy is a policy that denies all operations.
-// Useful for testing or as a circuit breaker.
-type DenyAllPolicy struct {
-	Reason string
+	return pr.runInner(ctx, op)
 }
 
-func (DenyAllPolicy) Name() string { return "deny-all" }
-
-func (p DenyAllPolicy) Check(ctx context.Context, op *PolicyOperation) (*PolicyResult, error) {
-	reason := p.Reason
-	if reason == "" {
-		reason = "all operations denied by policy"
+// runInner passes op on to the wrapped runner if it accepts operation
+// context, so a TransactionRunner or CachingRunner inside a PolicyRunner
+// still sees which operation is running.
+func (pr *PolicyRunner) runInner(ctx context.Context, op *PolicyOperation) (*Result, error) {
+	if r, ok := pr.inner.(operationRunner); ok {
+		return r.RunWithContext(ctx, op)
 	}
-	return &PolicyResult{Allowed: false, Reason: reason}, nil
-}
-
-// PackageBlocklistPolicy denies operations on specific packages.
-type PackageBlocklistPolicy struct {
-	Blocked map[string]string // package name -> reason
-}
-
-func (PackageBlocklistPolicy) Name() string { return "package-blocklist" }
-
-func (p PackageBlocklistPolicy) Check(ctx context.Context, op *PolicyOperation) (*PolicyResult, error) {
-	for _, pkg := range op.Packages {
-		if reason, blocked := p.Blocked[pkg]; blocked {
-			return &PolicyResult{
-				Allowed: false,
-				Reason:  reason,
-				Metadata: map[string]any{
-					"blocked_package": pkg,
-				},
-			}, nil
-		}
-	}
-	return &PolicyResult{Allowed: true}, nil
+	return pr.inner.Run(ctx, op.WorkingDir, op.Command...)
 }
@@ -4,7 +4,13 @@ import (
 	"context"
 	"errors"
 	"reflect"
+	"slices"
+	"strings"
 	"testing"
+	"testing/fstest"
+
+	"github.com/git-pkgs/managers/definitions"
+	"github.com/git-pkgs/managers/policies"
 )
 
 func TestPolicyRunnerAllowsWhenNoPolicies(t *testing.T) {
@@ -27,7 +33,7 @@ func TestPolicyRunnerAllowsWhenNoPolicies(t *testing.T) {
 
 func TestPolicyRunnerAllowAllPolicy(t *testing.T) {
 	mock := NewMockRunner()
-	pr := NewPolicyRunner(mock, WithPolicies(AllowAllPolicy{}))
+	pr := NewPolicyRunner(mock, WithPolicies(policies.AllowAllPolicy{}))
 
 	_, err := pr.Run(context.Background(), "/tmp", "npm", "install", "lodash")
 	if err != nil {
@@ -41,7 +47,7 @@ func TestPolicyRunnerAllowAllPolicy(t *testing.T) {
 
 func TestPolicyRunnerDenyAllPolicy(t *testing.T) {
 	mock := NewMockRunner()
-	pr := NewPolicyRunner(mock, WithPolicies(DenyAllPolicy{Reason: "testing"}))
+	pr := NewPolicyRunner(mock, WithPolicies(policies.DenyAllPolicy{Reason: "testing"}))
 
 	_, err := pr.Run(context.Background(), "/tmp", "npm", "install", "lodash")
 	if err == nil {
@@ -65,7 +71,7 @@ func TestPolicyRunnerDenyAllPolicy(t *testing.T) {
 func TestPolicyRunnerWarnMode(t *testing.T) {
 	mock := NewMockRunner()
 	pr := NewPolicyRunner(mock,
-		WithPolicies(DenyAllPolicy{Reason: "testing"}),
+		WithPolicies(policies.DenyAllPolicy{Reason: "testing"}),
 		WithPolicyMode(PolicyWarn),
 	)
 
@@ -82,7 +88,7 @@ func TestPolicyRunnerWarnMode(t *testing.T) {
 func TestPolicyRunnerDisabledMode(t *testing.T) {
 	mock := NewMockRunner()
 	pr := NewPolicyRunner(mock,
-		WithPolicies(DenyAllPolicy{Reason: "testing"}),
+		WithPolicies(policies.DenyAllPolicy{Reason: "testing"}),
 		WithPolicyMode(PolicyDisabled),
 	)
 
@@ -99,8 +105,8 @@ func TestPolicyRunnerDisabledMode(t *testing.T) {
 func TestPolicyRunnerMultiplePolicies(t *testing.T) {
 	mock := NewMockRunner()
 	pr := NewPolicyRunner(mock, WithPolicies(
-		AllowAllPolicy{},
-		AllowAllPolicy{},
+		policies.AllowAllPolicy{},
+		policies.AllowAllPolicy{},
 	))
 
 	_, err := pr.Run(context.Background(), "/tmp", "npm", "install")
@@ -116,9 +122,9 @@ func TestPolicyRunnerMultiplePolicies(t *testing.T) {
 func TestPolicyRunnerFirstDenyWins(t *testing.T) {
 	mock := NewMockRunner()
 	pr := NewPolicyRunner(mock, WithPolicies(
-		AllowAllPolicy{},
-		DenyAllPolicy{Reason: "second policy"},
-		AllowAllPolicy{},
+		policies.AllowAllPolicy{},
+		policies.DenyAllPolicy{Reason: "second policy"},
+		policies.AllowAllPolicy{},
 	))
 
 	_, err := pr.Run(context.Background(), "/tmp", "npm", "install")
@@ -136,42 +142,9 @@ func TestPolicyRunnerFirstDenyWins(t *testing.T) {
 	}
 }
 
-func TestPackageBlocklistPolicy(t *testing.T) {
-	policy := PackageBlocklistPolicy{
-		Blocked: map[string]string{
-			"evil-package":   "known malware",
-			"deprecated-lib": "no longer maintained",
-		},
-	}
-
-	tests := []struct {
-		name     string
-		packages []string
-		allowed  bool
-	}{
-		{"allowed package", []string{"lodash"}, true},
-		{"blocked package", []string{"evil-package"}, false},
-		{"mixed packages", []string{"lodash", "deprecated-lib"}, false},
-		{"empty packages", []string{}, true},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			op := &PolicyOperation{Packages: tt.packages}
-			result, err := policy.Check(context.Background(), op)
-			if err != nil {
-				t.Fatalf("unexpected error: %v", err)
-			}
-			if result.Allowed != tt.allowed {
-				t.Errorf("got allowed=%v, want %v", result.Allowed, tt.allowed)
-			}
-		})
-	}
-}
-
 func TestPolicyRunnerWithContext(t *testing.T) {
 	mock := NewMockRunner()
-	pr := NewPolicyRunner(mock, WithPolicies(AllowAllPolicy{}))
+	pr := NewPolicyRunner(mock, WithPolicies(policies.AllowAllPolicy{}))
 
 	op := &PolicyOperation{
 		Manager:    "npm",
@@ -194,7 +167,7 @@ func TestPolicyRunnerWithContext(t *testing.T) {
 func TestPolicyRunnerAddPolicy(t *testing.T) {
 	mock := NewMockRunner()
 	pr := NewPolicyRunner(mock)
-	pr.AddPolicy(DenyAllPolicy{Reason: "added later"})
+	pr.AddPolicy(policies.DenyAllPolicy{Reason: "added later"})
 
 	_, err := pr.Run(context.Background(), "/tmp", "npm", "install")
 	if err == nil {
@@ -214,7 +187,7 @@ func TestPolicyRunnerHandler(t *testing.T) {
 	mock := NewMockRunner()
 	handler := &handlerRecorder{}
 	pr := NewPolicyRunner(mock,
-		WithPolicies(AllowAllPolicy{}),
+		WithPolicies(policies.AllowAllPolicy{}),
 		WithPolicyHandler(handler),
 	)
 
@@ -236,6 +209,8 @@ type errorPolicy struct{}
 
 func (errorPolicy) Name() string { return "error-policy" }
 
+func (errorPolicy) Scope() []string { return []string{} }
+
 func (errorPolicy) Check(ctx context.Context, op *PolicyOperation) (*PolicyResult, error) {
 	return nil, errors.New("policy check failed")
 }
@@ -276,3 +251,155 @@ func TestPolicyModeString(t *testing.T) {
 		}
 	}
 }
+
+func TestApprovalPolicyBlocksWithContext(t *testing.T) {
+	mock := NewMockRunner()
+	pr := NewPolicyRunner(mock, WithPolicies(policies.ApprovalPolicy{
+		Prompt: func(op *PolicyOperation) (bool, error) { return false, nil },
+	}))
+
+	op := &PolicyOperation{
+		Manager:    "npm",
+		Operation:  "remove",
+		Packages:   []string{"lodash"},
+		WorkingDir: "/tmp",
+		Command:    []string{"npm", "uninstall", "lodash"},
+	}
+
+	_, err := pr.RunWithContext(context.Background(), op)
+	var violation *ErrPolicyViolation
+	if !errors.As(err, &violation) {
+		t.Fatalf("expected ErrPolicyViolation, got %v", err)
+	}
+	if violation.Policy != "approval" {
+		t.Errorf("got policy %q, want %q", violation.Policy, "approval")
+	}
+	if len(mock.Captured) != 0 {
+		t.Errorf("expected no commands executed, got %d", len(mock.Captured))
+	}
+}
+
+func TestPackageBlocklistPolicySeesManifestFile(t *testing.T) {
+	defs, err := definitions.LoadEmbedded()
+	if err != nil {
+		t.Fatalf("failed to load definitions: %v", err)
+	}
+
+	mock := NewMockRunner()
+	recorder := &opRecorder{}
+	pr := NewPolicyRunner(mock,
+		WithPolicies(policies.PackageBlocklistPolicy{Blocked: map[string]string{"event-stream": "compromised"}}),
+		WithPolicyHandler(recorder),
+	)
+
+	detector := NewDetector(NewTranslator(), pr)
+	detector.FS = fstest.MapFS{"package-lock.json": {Data: []byte("{}")}}
+	for _, def := range defs {
+		detector.Register(def)
+	}
+	mgr, err := detector.Detect(".", DetectOptions{})
+	if err != nil {
+		t.Fatalf("Detect failed: %v", err)
+	}
+
+	_, err = mgr.Add(context.Background(), "event-stream", AddOptions{})
+	var violation *ErrPolicyViolation
+	if !errors.As(err, &violation) {
+		t.Fatalf("expected ErrPolicyViolation, got %v", err)
+	}
+
+	if len(recorder.ops) != 1 {
+		t.Fatalf("expected 1 policy result, got %d", len(recorder.ops))
+	}
+	expected := "package-lock.json"
+	if recorder.ops[0].ManifestFile != expected {
+		t.Errorf("got ManifestFile %q, want %q", recorder.ops[0].ManifestFile, expected)
+	}
+}
+
+type scopedDenyPolicy struct {
+	scope []string
+}
+
+func (scopedDenyPolicy) Name() string { return "scoped-deny" }
+
+func (p scopedDenyPolicy) Scope() []string { return p.scope }
+
+func (scopedDenyPolicy) Check(ctx context.Context, op *PolicyOperation) (*PolicyResult, error) {
+	return &PolicyResult{Allowed: false, Reason: "denied in scope"}, nil
+}
+
+func TestPolicyRunnerSkipsOutOfScopePolicies(t *testing.T) {
+	mock := NewMockRunner()
+	pr := NewPolicyRunner(mock, WithPolicies(scopedDenyPolicy{scope: []string{"add", "update"}}))
+
+	list := &PolicyOperation{Operation: "list", WorkingDir: "/tmp", Command: []string{"npm", "list"}}
+	if _, err := pr.RunWithContext(context.Background(), list); err != nil {
+		t.Fatalf("expected out-of-scope operation to run, got %v", err)
+	}
+
+	add := &PolicyOperation{Operation: "add", WorkingDir: "/tmp", Command: []string{"npm", "install", "lodash"}}
+	_, err := pr.RunWithContext(context.Background(), add)
+	var violation *ErrPolicyViolation
+	if !errors.As(err, &violation) {
+		t.Fatalf("expected ErrPolicyViolation for in-scope operation, got %v", err)
+	}
+
+	if len(mock.Captured) != 1 {
+		t.Errorf("expected 1 command executed, got %d", len(mock.Captured))
+	}
+}
+
+func TestPolicyRunnerRunUsesCommandForScope(t *testing.T) {
+	mock := NewMockRunner()
+	pr := NewPolicyRunner(mock, WithPolicies(scopedDenyPolicy{scope: []string{"update"}}))
+
+	if _, err := pr.Run(context.Background(), "/tmp", "npm", "list"); err != nil {
+		t.Fatalf("expected out-of-scope command to run, got %v", err)
+	}
+	if _, err := pr.Run(context.Background(), "/tmp", "npm", "update"); err == nil {
+		t.Fatal("expected in-scope command to be denied")
+	}
+}
+
+// normalizingPolicy lowercases package names in place and records what it
+// was given.
+type normalizingPolicy struct {
+	seen *[][]string
+}
+
+func (normalizingPolicy) Name() string { return "normalizing" }
+
+func (normalizingPolicy) Scope() []string { return nil }
+
+func (p normalizingPolicy) Check(ctx context.Context, op *PolicyOperation) (*PolicyResult, error) {
+	*p.seen = append(*p.seen, slices.Clone(op.Packages))
+	for i, pkg := range op.Packages {
+		op.Packages[i] = strings.ToLower(pkg)
+	}
+	op.Command[0] = "mutated"
+	return &PolicyResult{Allowed: true}, nil
+}
+
+func TestPolicyRunnerIsolatesPolicies(t *testing.T) {
+	var seen [][]string
+	mock := NewMockRunner()
+	pr := NewPolicyRunner(mock, WithPolicies(normalizingPolicy{seen: &seen}, normalizingPolicy{seen: &seen}))
+
+	op := &PolicyOperation{
+		Operation:  "add",
+		Packages:   []string{"Lodash"},
+		WorkingDir: "/tmp",
+		Command:    []string{"npm", "install", "Lodash"},
+	}
+	if _, err := pr.RunWithContext(context.Background(), op); err != nil {
+		t.Fatalf("RunWithContext failed: %v", err)
+	}
+
+	for i, pkgs := range seen {
+		if !reflect.DeepEqual(pkgs, []string{"Lodash"}) {
+			t.Errorf("policy %d saw %v, want the original packages", i, pkgs)
+		}
+	}
+	mock.AssertCalled(t, "npm", "install", "Lodash")
+}
@@ -3,20 +3,64 @@ package managers
 import (
 	"bytes"
 	"context"
+	"io"
 	"os/exec"
+	"slices"
+	"strings"
+	"sync"
 	"time"
+
+	"github.com/git-pkgs/managers/definitions"
 )
 
 type Runner interface {
 	Run(ctx context.Context, dir string, args ...string) (*Result, error)
 }
 
-type ExecRunner struct{}
+type ExecRunner struct {
+	// StdinProvider, if set, is called once per command to supply its
+	// stdin, for managers that prompt for confirmation. Nil leaves stdin
+	// empty.
+	StdinProvider func() io.Reader
+
+	Options ExecOptions
+}
+
+// ExecOptions configures how an ExecRunner starts processes.
+type ExecOptions struct {
+	// RunAs runs each command as this user by prefixing it with
+	// sudo -u <user>. Empty runs commands as the current user.
+	RunAs string
+}
 
 func NewExecRunner() *ExecRunner {
 	return &ExecRunner{}
 }
 
+// NewExecRunnerWithStdin creates an ExecRunner that feeds each command the
+// reader returned by provider, such as strings.NewReader("y\n").
+func NewExecRunnerWithStdin(provider func() io.Reader) *ExecRunner {
+	return &ExecRunner{StdinProvider: provider}
+}
+
+// NewExecRunnerAs creates an ExecRunner that runs every command as user
+// through sudo, such as a deploy service account. sudo must be able to
+// switch to user without prompting for a password.
+func NewExecRunnerAs(user string) *ExecRunner {
+	return &ExecRunner{Options: ExecOptions{RunAs: user}}
+}
+
+// command returns the full argument list to execute for args.
+func (r *ExecRunner) command(args []string) []string {
+	if r.Options.RunAs == "" {
+		return args
+	}
+	return append([]string{"sudo", "-u", r.Options.RunAs}, args...)
+}
+
+// Run executes args in dir. A command that starts and exits non-zero is not
+// an error: the status is only reported in Result.ExitCode. Wrap the runner
+// in an ExitCodeAwareRunner to turn failing exit codes into errors.
 func (r *ExecRunner) Run(ctx context.Context, dir string, args ...string) (*Result, error) {
 	if len(args) == 0 {
 		return nil, ErrNoCommand
@@ -24,12 +68,16 @@ func (r *ExecRunner) Run(ctx context.Context, dir string, args ...string) (*Resu
 
 	start := time.Now()
 
+	args = r.command(args)
 	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
 	cmd.Dir = dir
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
+	if r.StdinProvider != nil {
+		cmd.Stdin = r.StdinProvider()
+	}
 
 	err := cmd.Run()
 
@@ -55,11 +103,78 @@ func (r *ExecRunner) Run(ctx context.Context, dir string, args ...string) (*Resu
 	return result, nil
 }
 
+// ExitCodeAwareRunner wraps a Runner and consults the definition's
+// exit_codes for each operation. A non-zero exit code the definition
+// documents as something other than "error" (npm outdated exiting 1, for
+// example) is a normal result; any other non-zero exit code is returned as
+// ErrCommandFailed along with the result.
+type ExitCodeAwareRunner struct {
+	inner Runner
+	def   *definitions.Definition
+}
+
+// NewExitCodeAwareRunner creates an ExitCodeAwareRunner for def.
+func NewExitCodeAwareRunner(inner Runner, def *definitions.Definition) *ExitCodeAwareRunner {
+	return &ExitCodeAwareRunner{inner: inner, def: def}
+}
+
+// Run forwards the command unchanged; without operation context there is
+// no way to tell which exit_codes apply.
+func (r *ExitCodeAwareRunner) Run(ctx context.Context, dir string, args ...string) (*Result, error) {
+	return r.inner.Run(ctx, dir, args...)
+}
+
+// RunWithContext runs op.Command and interprets its exit code using the
+// exit_codes of op.Operation. If the wrapped runner accepts operation
+// context it is passed through.
+func (r *ExitCodeAwareRunner) RunWithContext(ctx context.Context, op *PolicyOperation) (*Result, error) {
+	var result *Result
+	var err error
+	if inner, ok := r.inner.(operationRunner); ok {
+		result, err = inner.RunWithContext(ctx, op)
+	} else {
+		result, err = r.inner.Run(ctx, op.WorkingDir, op.Command...)
+	}
+
+	if result == nil || result.ExitCode <= 0 {
+		return result, err
+	}
+	if r.expected(op.Operation, result.ExitCode) {
+		return result, nil
+	}
+	if err != nil {
+		return result, err
+	}
+	return result, ErrCommandFailed{
+		Manager:   r.def.Name,
+		Operation: op.Operation,
+		ExitCode:  result.ExitCode,
+	}
+}
+
+func (r *ExitCodeAwareRunner) expected(operation string, code int) bool {
+	cmd, ok := r.def.Commands[operation]
+	if !ok {
+		return false
+	}
+	meaning, ok := cmd.ExitCodes[code]
+	return ok && meaning != "error"
+}
+
 type MockRunner struct {
 	Captured [][]string
 	Results  []*Result
 	Errors   []error
 	callIdx  int
+
+	scenarios []mockScenario
+}
+
+// mockScenario is a canned response for an exact argument list.
+type mockScenario struct {
+	args   []string
+	result *Result
+	err    error
 }
 
 func NewMockRunner() *MockRunner {
@@ -69,6 +184,12 @@ func NewMockRunner() *MockRunner {
 func (m *MockRunner) Run(ctx context.Context, dir string, args ...string) (*Result, error) {
 	m.Captured = append(m.Captured, args)
 
+	for _, sc := range m.scenarios {
+		if slices.Equal(sc.args, args) {
+			return sc.result, sc.err
+		}
+	}
+
 	idx := m.callIdx
 	m.callIdx++
 
@@ -87,9 +208,154 @@ func (m *MockRunner) Run(ctx context.Context, dir string, args ...string) (*Resu
 	}, nil
 }
 
+// OnArgs registers a response for calls whose args exactly match args.
+// Matching calls return result and err and do not advance the positional
+// Results and Errors; other calls fall back to them.
+func (m *MockRunner) OnArgs(args []string, result *Result, err error) {
+	m.scenarios = append(m.scenarios, mockScenario{
+		args:   slices.Clone(args),
+		result: result,
+		err:    err,
+	})
+}
+
 func (m *MockRunner) LastCaptured() []string {
 	if len(m.Captured) == 0 {
 		return nil
 	}
 	return m.Captured[len(m.Captured)-1]
 }
+
+// TestingT is the part of testing.TB that MockRunner's assertions use.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// AssertCalled reports an error on t unless at least one captured call
+// matches args. A "*" in args matches any single argument.
+func (m *MockRunner) AssertCalled(t TestingT, args ...string) bool {
+	t.Helper()
+	if m.countCalls(args) == 0 {
+		t.Errorf("expected a call matching %v, got %v", args, m.Captured)
+		return false
+	}
+	return true
+}
+
+// AssertCalledNTimes reports an error on t unless exactly n captured calls
+// match args. A "*" in args matches any single argument.
+func (m *MockRunner) AssertCalledNTimes(t TestingT, n int, args ...string) bool {
+	t.Helper()
+	if got := m.countCalls(args); got != n {
+		t.Errorf("expected %d calls matching %v, got %d in %v", n, args, got, m.Captured)
+		return false
+	}
+	return true
+}
+
+func (m *MockRunner) countCalls(pattern []string) int {
+	count := 0
+	for _, args := range m.Captured {
+		if matchArgs(pattern, args) {
+			count++
+		}
+	}
+	return count
+}
+
+// matchArgs reports whether args matches pattern, where "*" matches any
+// single argument.
+func matchArgs(pattern, args []string) bool {
+	if len(pattern) != len(args) {
+		return false
+	}
+	for i, p := range pattern {
+		if p != "*" && p != args[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// CachingRunner wraps a Runner and reuses results for repeated read-only
+// commands with the same directory and arguments. Commands for operations
+// not in CachableOperations are always forwarded, and drop the cached
+// results for their directory since they may have changed what a list or
+// outdated would report. Only commands that exit 0 are cached.
+type CachingRunner struct {
+	inner Runner
+	cache sync.Map
+
+	// CachableOperations lists the operations whose results may be reused.
+	CachableOperations []string
+}
+
+// NewCachingRunner creates a CachingRunner that caches list, outdated and path.
+func NewCachingRunner(inner Runner) *CachingRunner {
+	return &CachingRunner{
+		inner:              inner,
+		CachableOperations: []string{"list", "outdated", "path"},
+	}
+}
+
+// Run executes the command, or returns the cached result of an identical
+// earlier call. Without operation context the operation is taken to be the
+// first argument after the binary, so prefer RunWithContext where possible.
+func (c *CachingRunner) Run(ctx context.Context, dir string, args ...string) (*Result, error) {
+	operation := ""
+	if len(args) > 1 {
+		operation = args[1]
+	}
+	return c.run(operation, dir, args, func() (*Result, error) {
+		return c.inner.Run(ctx, dir, args...)
+	})
+}
+
+// RunWithContext executes op.Command, using op.Operation to decide whether
+// the result may be cached. If the wrapped runner accepts operation context
+// (a PolicyRunner, for example) it is passed through.
+func (c *CachingRunner) RunWithContext(ctx context.Context, op *PolicyOperation) (*Result, error) {
+	return c.run(op.Operation, op.WorkingDir, op.Command, func() (*Result, error) {
+		if r, ok := c.inner.(operationRunner); ok {
+			return r.RunWithContext(ctx, op)
+		}
+		return c.inner.Run(ctx, op.WorkingDir, op.Command...)
+	})
+}
+
+// Clear drops all cached results.
+func (c *CachingRunner) Clear() {
+	c.cache.Clear()
+}
+
+func (c *CachingRunner) run(operation, dir string, args []string, fn func() (*Result, error)) (*Result, error) {
+	prefix := dir + "\x00"
+	if !slices.Contains(c.CachableOperations, operation) {
+		result, err := fn()
+		c.clearDir(prefix)
+		return result, err
+	}
+
+	key := prefix + strings.Join(args, "\x00")
+	if cached, ok := c.cache.Load(key); ok {
+		return cached.(*Result), nil
+	}
+
+	result, err := fn()
+	if err != nil || result == nil || result.ExitCode != 0 {
+		return result, err
+	}
+	c.cache.Store(key, result)
+	return result, nil
+}
+
+// clearDir drops the cached results whose keys start with prefix.
+func (c *CachingRunner) clearDir(prefix string) {
+	c.cache.Range(func(key, _ any) bool {
+		if strings.HasPrefix(key.(string), prefix) {
+			c.cache.Delete(key)
+		}
+		return true
+	})
+}
@@ -0,0 +1,389 @@
+package managers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/git-pkgs/managers/definitions"
+)
+
+func TestCachingRunnerCachesReadOperations(t *testing.T) {
+	mock := NewMockRunner()
+	mock.Results = []*Result{{Stdout: "first"}, {Stdout: "second"}}
+	cr := NewCachingRunner(mock)
+
+	first, err := cr.Run(context.Background(), "/tmp", "npm", "list", "--json")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	second, err := cr.Run(context.Background(), "/tmp", "npm", "list", "--json")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(mock.Captured) != 1 {
+		t.Fatalf("expected 1 command executed, got %d", len(mock.Captured))
+	}
+	if first != second {
+		t.Errorf("expected cached result to be returned")
+	}
+}
+
+func TestCachingRunnerMissesOnDifferentArgs(t *testing.T) {
+	mock := NewMockRunner()
+	cr := NewCachingRunner(mock)
+
+	_, _ = cr.Run(context.Background(), "/tmp", "npm", "list", "--json")
+	_, _ = cr.Run(context.Background(), "/tmp", "npm", "list", "--depth", "0")
+	_, _ = cr.Run(context.Background(), "/other", "npm", "list", "--json")
+
+	if len(mock.Captured) != 3 {
+		t.Errorf("expected 3 commands executed, got %d", len(mock.Captured))
+	}
+}
+
+func TestCachingRunnerForwardsWrites(t *testing.T) {
+	mock := NewMockRunner()
+	cr := NewCachingRunner(mock)
+
+	_, _ = cr.Run(context.Background(), "/tmp", "npm", "install", "lodash")
+	_, _ = cr.Run(context.Background(), "/tmp", "npm", "install", "lodash")
+
+	if len(mock.Captured) != 2 {
+		t.Errorf("expected 2 commands executed, got %d", len(mock.Captured))
+	}
+}
+
+func TestCachingRunnerDoesNotCacheErrors(t *testing.T) {
+	mock := NewMockRunner()
+	mock.Errors = []error{errors.New("network down")}
+	cr := NewCachingRunner(mock)
+
+	if _, err := cr.Run(context.Background(), "/tmp", "npm", "outdated"); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if _, err := cr.Run(context.Background(), "/tmp", "npm", "outdated"); err != nil {
+		t.Fatalf("expected retry to succeed, got %v", err)
+	}
+
+	if len(mock.Captured) != 2 {
+		t.Errorf("expected 2 commands executed, got %d", len(mock.Captured))
+	}
+}
+
+func TestCachingRunnerDoesNotCacheFailedExits(t *testing.T) {
+	mock := NewMockRunner()
+	mock.Results = []*Result{{ExitCode: 1, Stderr: "network down"}, {Stdout: "{}"}}
+	cr := NewCachingRunner(mock)
+
+	first, _ := cr.Run(context.Background(), "/tmp", "npm", "outdated")
+	second, _ := cr.Run(context.Background(), "/tmp", "npm", "outdated")
+
+	if len(mock.Captured) != 2 {
+		t.Errorf("expected 2 commands executed, got %d", len(mock.Captured))
+	}
+	if first.ExitCode != 1 || second.ExitCode != 0 {
+		t.Errorf("got exit codes %d and %d, want 1 then 0", first.ExitCode, second.ExitCode)
+	}
+}
+
+func TestCachingRunnerWriteClearsDirectory(t *testing.T) {
+	mock := NewMockRunner()
+	cr := NewCachingRunner(mock)
+	ctx := context.Background()
+
+	_, _ = cr.Run(ctx, "/tmp", "npm", "list", "--json")
+	_, _ = cr.Run(ctx, "/other", "npm", "list", "--json")
+	_, _ = cr.Run(ctx, "/tmp", "npm", "install", "lodash")
+	_, _ = cr.Run(ctx, "/tmp", "npm", "list", "--json")
+	_, _ = cr.Run(ctx, "/other", "npm", "list", "--json")
+
+	// /tmp lists again after the install; /other stays cached
+	mock.AssertCalledNTimes(t, 3, "npm", "list", "--json")
+}
+
+func TestGenericManager_CachedListAfterAdd(t *testing.T) {
+	mock := NewMockRunner()
+	mgr := NewGenericManager(embeddedDefinition(t, "npm"), "/test/project", WithRunner(NewCachingRunner(mock)))
+	ctx := context.Background()
+
+	if _, err := mgr.List(ctx, ListOptions{}); err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if _, err := mgr.Add(ctx, "lodash", AddOptions{}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if _, err := mgr.List(ctx, ListOptions{}); err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+
+	mock.AssertCalledNTimes(t, 2, "npm", "list", "--json")
+}
+
+func TestCachingRunnerWithContextUsesOperation(t *testing.T) {
+	mock := NewMockRunner()
+	cr := NewCachingRunner(mock)
+
+	// npm's path command is "npm ls", so only the operation identifies it as cachable
+	op := &PolicyOperation{
+		Operation:  "path",
+		WorkingDir: "/tmp",
+		Command:    []string{"npm", "ls", "lodash", "--parseable"},
+	}
+	_, _ = cr.RunWithContext(context.Background(), op)
+	_, _ = cr.RunWithContext(context.Background(), op)
+
+	if len(mock.Captured) != 1 {
+		t.Errorf("expected 1 command executed, got %d", len(mock.Captured))
+	}
+}
+
+func TestCachingRunnerCustomOperationsAndClear(t *testing.T) {
+	mock := NewMockRunner()
+	cr := NewCachingRunner(mock)
+	cr.CachableOperations = []string{"resolve"}
+
+	op := &PolicyOperation{Operation: "resolve", WorkingDir: "/tmp", Command: []string{"go", "mod", "graph"}}
+	_, _ = cr.RunWithContext(context.Background(), op)
+	_, _ = cr.RunWithContext(context.Background(), op)
+	if len(mock.Captured) != 1 {
+		t.Fatalf("expected 1 command executed, got %d", len(mock.Captured))
+	}
+
+	cr.Clear()
+	_, _ = cr.RunWithContext(context.Background(), op)
+	if len(mock.Captured) != 2 {
+		t.Errorf("expected cache miss after Clear, got %d commands", len(mock.Captured))
+	}
+}
+
+func TestMockRunnerOnArgs(t *testing.T) {
+	mock := NewMockRunner()
+	mock.Results = []*Result{{Stdout: "positional"}}
+	mock.OnArgs([]string{"npm", "list"}, &Result{Stdout: "list"}, nil)
+	boom := errors.New("boom")
+	mock.OnArgs([]string{"npm", "outdated"}, nil, boom)
+
+	result, err := mock.Run(context.Background(), "/tmp", "npm", "list")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if result.Stdout != "list" {
+		t.Errorf("expected scenario result, got %q", result.Stdout)
+	}
+
+	if _, err := mock.Run(context.Background(), "/tmp", "npm", "outdated"); !errors.Is(err, boom) {
+		t.Errorf("expected scenario error, got %v", err)
+	}
+
+	result, err = mock.Run(context.Background(), "/tmp", "npm", "install")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if result.Stdout != "positional" {
+		t.Errorf("expected positional fallback, got %q", result.Stdout)
+	}
+
+	if len(mock.Captured) != 3 {
+		t.Errorf("expected 3 captured commands, got %d", len(mock.Captured))
+	}
+}
+
+// recordingT captures assertion failures so they can be checked.
+type recordingT struct {
+	errors []string
+}
+
+func (r *recordingT) Helper() {}
+
+func (r *recordingT) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestMockRunnerAssertCalled(t *testing.T) {
+	mock := NewMockRunner()
+	ctx := context.Background()
+	_, _ = mock.Run(ctx, "/tmp", "npm", "install", "lodash")
+	_, _ = mock.Run(ctx, "/tmp", "npm", "install", "express")
+	_, _ = mock.Run(ctx, "/tmp", "npm", "test")
+
+	tests := []struct {
+		name   string
+		assert func(TestingT) bool
+		pass   bool
+	}{
+		{"exact", func(rt TestingT) bool { return mock.AssertCalled(rt, "npm", "test") }, true},
+		{"wildcard", func(rt TestingT) bool { return mock.AssertCalled(rt, "npm", "install", "*") }, true},
+		{"never called", func(rt TestingT) bool { return mock.AssertCalled(rt, "npm", "ci") }, false},
+		{"wildcard needs an argument", func(rt TestingT) bool { return mock.AssertCalled(rt, "npm", "test", "*") }, false},
+		{"count", func(rt TestingT) bool { return mock.AssertCalledNTimes(rt, 2, "npm", "install", "*") }, true},
+		{"wrong count", func(rt TestingT) bool { return mock.AssertCalledNTimes(rt, 1, "npm", "install", "*") }, false},
+		{"zero count", func(rt TestingT) bool { return mock.AssertCalledNTimes(rt, 0, "npm", "ci") }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := &recordingT{}
+			if got := tt.assert(rt); got != tt.pass {
+				t.Errorf("got %v, want %v", got, tt.pass)
+			}
+			if failed := len(rt.errors) > 0; failed == tt.pass {
+				t.Errorf("unexpected errors %v", rt.errors)
+			}
+		})
+	}
+}
+
+func TestExitCodeAwareRunner(t *testing.T) {
+	def := &definitions.Definition{
+		Name:   "npm",
+		Binary: "npm",
+		Commands: map[string]definitions.Command{
+			"outdated": {
+				Base:      []string{"outdated", "--json"},
+				ExitCodes: map[int]string{0: "success", 1: "outdated"},
+			},
+			"install": {
+				Base:      []string{"install"},
+				ExitCodes: map[int]string{0: "success", 1: "error"},
+			},
+		},
+		Capabilities: []string{"install", "outdated"},
+	}
+
+	// Like ExecRunner, report the exit status only in the result
+	mock := NewMockRunner()
+	mock.OnArgs([]string{"npm", "outdated", "--json"}, &Result{ExitCode: 1, Stdout: "{}"}, nil)
+	mock.OnArgs([]string{"npm", "install"}, &Result{ExitCode: 1}, nil)
+
+	mgr := NewGenericManager(def, "/test/project", WithRunner(NewExitCodeAwareRunner(mock, def)))
+
+	result, err := mgr.Outdated(context.Background())
+	if err != nil {
+		t.Fatalf("expected documented exit code to be accepted, got %v", err)
+	}
+	if result.ExitCode != 1 {
+		t.Errorf("expected exit code 1, got %d", result.ExitCode)
+	}
+
+	_, err = mgr.Install(context.Background(), InstallOptions{})
+	want := ErrCommandFailed{Manager: "npm", Operation: "install", ExitCode: 1}
+	if !errors.Is(err, want) {
+		t.Errorf("got %v, want %v", err, want)
+	}
+}
+
+func TestExitCodeAwareRunnerExecRunner(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	def := &definitions.Definition{
+		Name:   "testpkg",
+		Binary: "sh",
+		Commands: map[string]definitions.Command{
+			"outdated": {
+				Base:      []string{"-c", "exit 1"},
+				ExitCodes: map[int]string{0: "success", 1: "outdated"},
+			},
+			"install": {
+				Base:      []string{"-c", "exit 1"},
+				ExitCodes: map[int]string{0: "success", 1: "error"},
+			},
+			// Undocumented codes are failures too
+			"list": {
+				Base:      []string{"-c", "exit 2"},
+				ExitCodes: map[int]string{0: "success", 1: "error"},
+			},
+		},
+		Capabilities: []string{"install", "list", "outdated"},
+	}
+	mgr := NewGenericManager(def, t.TempDir(), WithRunner(NewExitCodeAwareRunner(NewExecRunner(), def)))
+	ctx := context.Background()
+
+	result, err := mgr.Outdated(ctx)
+	if err != nil {
+		t.Fatalf("expected documented exit code to be accepted, got %v", err)
+	}
+	if result.ExitCode != 1 {
+		t.Errorf("expected exit code 1, got %d", result.ExitCode)
+	}
+
+	result, err = mgr.Install(ctx, InstallOptions{})
+	if !errors.As(err, new(ErrCommandFailed)) {
+		t.Errorf("expected ErrCommandFailed for install, got %v", err)
+	}
+	if result == nil || result.ExitCode != 1 {
+		t.Errorf("expected the result to be returned with the error, got %+v", result)
+	}
+
+	if _, err := mgr.List(ctx, ListOptions{}); !errors.Is(err, ErrCommandFailed{Manager: "testpkg", Operation: "list", ExitCode: 2}) {
+		t.Errorf("expected ErrCommandFailed for list, got %v", err)
+	}
+}
+
+func TestExecRunnerStdinProvider(t *testing.T) {
+	if _, err := exec.LookPath("cat"); err != nil {
+		t.Skip("cat not available")
+	}
+
+	calls := 0
+	r := NewExecRunnerWithStdin(func() io.Reader {
+		calls++
+		return strings.NewReader("y\n")
+	})
+
+	for range 2 {
+		result, err := r.Run(context.Background(), t.TempDir(), "cat")
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if result.Stdout != "y\n" {
+			t.Errorf("got stdout %q, want %q", result.Stdout, "y\n")
+		}
+	}
+	if calls != 2 {
+		t.Errorf("expected provider to be called once per command, got %d", calls)
+	}
+
+	result, err := NewExecRunner().Run(context.Background(), t.TempDir(), "cat")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result.Stdout != "" {
+		t.Errorf("expected no stdin by default, got %q", result.Stdout)
+	}
+}
+
+func TestExecRunnerRunAs(t *testing.T) {
+	args := []string{"npm", "install"}
+
+	got := NewExecRunnerAs("deploy").command(args)
+	expected := []string{"sudo", "-u", "deploy", "npm", "install"}
+	if !slices.Equal(got, expected) {
+		t.Errorf("got %v, want %v", got, expected)
+	}
+
+	// Without RunAs the command is left alone; this is also the only
+	// configuration actually executed here, so the test never needs sudo
+	r := NewExecRunner()
+	if got := r.command(args); !slices.Equal(got, args) {
+		t.Errorf("got %v, want %v", got, args)
+	}
+	if _, err := exec.LookPath("true"); err != nil {
+		t.Skip("true not available")
+	}
+	result, err := r.Run(context.Background(), t.TempDir(), "true")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if !slices.Equal(result.Command, []string{"true"}) {
+		t.Errorf("got command %v, want [true]", result.Command)
+	}
+}
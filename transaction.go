@@ -0,0 +1,170 @@
+package managers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// TransactionRunner wraps a Runner and records the contents of a manager's
+// lockfiles and manifests before each write operation, so a series of
+// changes can be undone with Rollback or kept with Commit.
+//
+// Only calls made through RunWithContext (which GenericManager does
+// automatically) are tracked; plain Run calls carry no manager or operation
+// and are forwarded untouched.
+type TransactionRunner struct {
+	inner      Runner
+	translator *Translator
+
+	mu        sync.Mutex
+	snapshots map[string]fileSnapshot
+	order     []string
+
+	// WriteOperations lists the operations that trigger a snapshot.
+	WriteOperations []string
+}
+
+type fileSnapshot struct {
+	data   []byte
+	mode   fs.FileMode
+	exists bool
+}
+
+// NewTransactionRunner creates a TransactionRunner that snapshots files
+// before add, remove, update and install. The translator supplies each
+// manager's definition so its lockfiles and manifests can be found.
+func NewTransactionRunner(inner Runner, translator *Translator) *TransactionRunner {
+	return &TransactionRunner{
+		inner:           inner,
+		translator:      translator,
+		snapshots:       make(map[string]fileSnapshot),
+		WriteOperations: []string{"add", "remove", "update", "install"},
+	}
+}
+
+// Run forwards the command without tracking it.
+func (t *TransactionRunner) Run(ctx context.Context, dir string, args ...string) (*Result, error) {
+	return t.inner.Run(ctx, dir, args...)
+}
+
+// RunWithContext snapshots the manager's files if op is a write operation,
+// then runs it. If the wrapped runner accepts operation context it is
+// passed through.
+func (t *TransactionRunner) RunWithContext(ctx context.Context, op *PolicyOperation) (*Result, error) {
+	if slices.Contains(t.WriteOperations, op.Operation) {
+		if err := t.snapshot(op); err != nil {
+			return nil, err
+		}
+	}
+
+	if r, ok := t.inner.(operationRunner); ok {
+		return r.RunWithContext(ctx, op)
+	}
+	return t.inner.Run(ctx, op.WorkingDir, op.Command...)
+}
+
+// Commit keeps all changes made since the transaction began and starts a
+// new one.
+func (t *TransactionRunner) Commit() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.reset()
+}
+
+// Rollback restores every tracked file to the contents it had before the
+// first write operation touched it. Files that did not exist then are
+// removed. The transaction is reset even if some files fail to restore.
+func (t *TransactionRunner) Rollback() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var errs []error
+	for _, path := range t.order {
+		snap := t.snapshots[path]
+		if !snap.exists {
+			if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if err := os.WriteFile(path, snap.data, snap.mode); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	t.reset()
+	return errors.Join(errs...)
+}
+
+// Tracked returns the paths of the files that Rollback would restore.
+func (t *TransactionRunner) Tracked() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return slices.Clone(t.order)
+}
+
+func (t *TransactionRunner) reset() {
+	t.snapshots = make(map[string]fileSnapshot)
+	t.order = nil
+}
+
+func (t *TransactionRunner) snapshot(op *PolicyOperation) error {
+	def, ok := t.translator.Definition(op.Manager)
+	if !ok {
+		return fmt.Errorf("unknown manager: %s", op.Manager)
+	}
+
+	var paths []string
+	patterns := append(slices.Clone(def.Detection.Lockfiles), def.Detection.Manifests...)
+	for _, pattern := range patterns {
+		full := filepath.Join(op.WorkingDir, pattern)
+		if !strings.ContainsAny(pattern, `*?[\`) {
+			paths = append(paths, full)
+			continue
+		}
+		matches, err := filepath.Glob(full)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, matches...)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, path := range paths {
+		// Keep the earliest contents; later writes in the same transaction
+		// must roll back to the state before the first one.
+		if _, seen := t.snapshots[path]; seen {
+			continue
+		}
+
+		info, err := os.Stat(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			t.snapshots[path] = fileSnapshot{}
+			t.order = append(t.order, path)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		t.snapshots[path] = fileSnapshot{data: data, mode: info.Mode().Perm(), exists: true}
+		t.order = append(t.order, path)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package managers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/git-pkgs/managers/definitions"
+)
+
+func newTransactionManager(t *testing.T, dir string) (*GenericManager, *TransactionRunner) {
+	t.Helper()
+	def := &definitions.Definition{
+		Name:   "testpkg",
+		Binary: "testpkg",
+		Detection: definitions.Detection{
+			Lockfiles: []string{"test.lock"},
+			Manifests: []string{"test.json"},
+		},
+		Commands: map[string]definitions.Command{
+			"add": {
+				Base: []string{"add"},
+				Args: map[string]definitions.Arg{
+					"package": {Position: 0, Required: true},
+				},
+			},
+			"list": {Base: []string{"list"}},
+		},
+		Capabilities: []string{"add", "list"},
+	}
+	translator := NewTranslator()
+	translator.Register(def)
+	tr := NewTransactionRunner(NewMockRunner(), translator)
+	return NewGenericManager(def, dir, WithTranslator(translator), WithRunner(tr)), tr
+}
+
+func TestTransactionRunnerRollback(t *testing.T) {
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "test.json")
+	lockfile := filepath.Join(dir, "test.lock")
+	if err := os.WriteFile(manifest, []byte("original"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mgr, tr := newTransactionManager(t, dir)
+	if _, err := mgr.Add(context.Background(), "lodash", AddOptions{}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	// Simulate what the real command would have written
+	if err := os.WriteFile(manifest, []byte("changed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(lockfile, []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A second write must not overwrite the first snapshot
+	if _, err := mgr.Add(context.Background(), "express", AddOptions{}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	if err := tr.Rollback(); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+
+	data, err := os.ReadFile(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "original" {
+		t.Errorf("expected manifest restored, got %q", data)
+	}
+	if _, err := os.Stat(lockfile); !os.IsNotExist(err) {
+		t.Errorf("expected lockfile created during transaction to be removed, got %v", err)
+	}
+	if len(tr.Tracked()) != 0 {
+		t.Errorf("expected no tracked files after rollback, got %v", tr.Tracked())
+	}
+}
+
+func TestTransactionRunnerCommit(t *testing.T) {
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "test.json")
+	if err := os.WriteFile(manifest, []byte("original"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mgr, tr := newTransactionManager(t, dir)
+	if _, err := mgr.Add(context.Background(), "lodash", AddOptions{}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := os.WriteFile(manifest, []byte("changed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tr.Commit()
+	if err := tr.Rollback(); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+
+	data, err := os.ReadFile(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "changed" {
+		t.Errorf("expected committed changes kept, got %q", data)
+	}
+}
+
+func TestTransactionRunnerIgnoresReadOperations(t *testing.T) {
+	dir := t.TempDir()
+	mgr, tr := newTransactionManager(t, dir)
+
+	if _, err := mgr.List(context.Background(), ListOptions{}); err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(tr.Tracked()) != 0 {
+		t.Errorf("expected list not to snapshot files, got %v", tr.Tracked())
+	}
+}
+
+func TestTransactionRunnerInsidePolicyRunner(t *testing.T) {
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "test.json")
+	if err := os.WriteFile(manifest, []byte("original"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mgr, tr := newTransactionManager(t, dir)
+	mgr = NewGenericManager(mgr.def, dir, WithTranslator(mgr.translator), WithRunner(NewPolicyRunner(tr)))
+	if _, err := mgr.Add(context.Background(), "lodash", AddOptions{}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	if err := os.WriteFile(manifest, []byte("changed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Rollback(); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+
+	data, err := os.ReadFile(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "original" {
+		t.Errorf("expected manifest restored through the policy runner, got %q", data)
+	}
+}
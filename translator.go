@@ -2,7 +2,10 @@ package managers
 
 import (
 	"fmt"
+	"os"
+	"slices"
 	"sort"
+	"strings"
 
 	"github.com/git-pkgs/managers/definitions"
 )
@@ -36,6 +39,10 @@ type CommandInput struct {
 	Args  map[string]string
 	Flags map[string]any
 	Extra []string // Raw arguments appended to the command (escape hatch)
+
+	// BinaryOverride replaces the definition's binary, for versioned names
+	// such as node18. Commands that name their own binary keep it.
+	BinaryOverride string
 }
 
 func (t *Translator) BuildCommand(managerName, operation string, input CommandInput) ([]string, error) {
@@ -45,94 +52,251 @@ func (t *Translator) BuildCommand(managerName, operation string, input CommandIn
 	}
 
 	cmd, ok := def.Commands[operation]
-	if !ok {
-		return nil, ErrUnsupportedOperation
+	if !ok || cmd.Unsupported != "" {
+		return nil, unsupportedOperation(def, cmd.Unsupported)
+	}
+
+	if err := validateExtra(def.SafeExtraArgs[operation], input.Extra); err != nil {
+		return nil, err
 	}
 
-	return t.buildSingleCommand(def.Binary, cmd, input)
+	return t.buildSingleCommand(binaryFor(def, input), cmd, input)
 }
 
-// BuildCommands returns all commands for an operation (including "then" chains)
-func (t *Translator) BuildCommands(managerName, operation string, input CommandInput) ([][]string, error) {
+// binaryFor returns the binary commands for def are built with.
+func binaryFor(def *definitions.Definition, input CommandInput) string {
+	if input.BinaryOverride != "" {
+		return input.BinaryOverride
+	}
+	return def.Binary
+}
+
+// unsupportedOperation returns ErrUnsupportedOperation with the command's
+// hint, if any, and a pointer to the manager's documentation.
+func unsupportedOperation(def *definitions.Definition, hint string) error {
+	switch {
+	case hint != "" && def.Website != "":
+		return fmt.Errorf("%w: %s (see %s)", ErrUnsupportedOperation, hint, def.Website)
+	case hint != "":
+		return fmt.Errorf("%w: %s", ErrUnsupportedOperation, hint)
+	case def.Website != "":
+		return fmt.Errorf("%w (see %s)", ErrUnsupportedOperation, def.Website)
+	default:
+		return ErrUnsupportedOperation
+	}
+}
+
+// validateExtra checks extra args against an allowlist of flags. An empty
+// allowlist accepts everything. Each flag (matched before any "=") must be
+// listed; other values are only accepted directly after an allowed flag.
+func validateExtra(allowed, extra []string) error {
+	if errs := extraErrors(allowed, extra); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// extraErrors returns an error for every extra arg validateExtra would reject.
+func extraErrors(allowed, extra []string) []error {
+	if len(allowed) == 0 {
+		return nil
+	}
+
+	var errs []error
+	afterFlag := false
+	for _, arg := range extra {
+		if !strings.HasPrefix(arg, "-") {
+			if !afterFlag {
+				errs = append(errs, fmt.Errorf("%w: %s", ErrUnsupportedOption, arg))
+			}
+			afterFlag = false
+			continue
+		}
+
+		name, _, hasValue := strings.Cut(arg, "=")
+		if !slices.Contains(allowed, name) {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrUnsupportedOption, name))
+			afterFlag = false
+			continue
+		}
+		afterFlag = !hasValue
+	}
+	return errs
+}
+
+// ChainMetadata describes the commands returned by BuildCommands.
+// Steps[i] describes commands[i].
+type ChainMetadata struct {
+	Steps []ChainStep
+}
+
+// ChainStep describes one command in a chain.
+type ChainStep struct {
+	Label    string // the definition's label, or the binary and base (e.g. "go mod tidy")
+	Optional bool   // the operation can be considered successful even if this step fails
+}
+
+// BuildCommands returns all commands for an operation, in the order they
+// should run: "before" steps, the main command, then "then" steps, along with
+// metadata describing each step.
+func (t *Translator) BuildCommands(managerName, operation string, input CommandInput) ([][]string, *ChainMetadata, error) {
 	def, ok := t.definitions[managerName]
 	if !ok {
-		return nil, fmt.Errorf("unknown manager: %s", managerName)
+		return nil, nil, fmt.Errorf("unknown manager: %s", managerName)
 	}
 
 	cmd, ok := def.Commands[operation]
-	if !ok {
-		return nil, ErrUnsupportedOperation
+	if !ok || cmd.Unsupported != "" {
+		return nil, nil, unsupportedOperation(def, cmd.Unsupported)
+	}
+
+	if err := validateExtra(def.SafeExtraArgs[operation], input.Extra); err != nil {
+		return nil, nil, err
 	}
 
-	return t.buildCommandChain(def.Binary, cmd, input)
+	return t.buildCommandChain(def, operation, cmd, input)
 }
 
-func (t *Translator) buildCommandChain(binary string, cmd definitions.Command, input CommandInput) ([][]string, error) {
-	first, err := t.buildSingleCommand(binary, cmd, input)
+// BuildPrimaryCommand returns the main command of an operation, without any
+// "before" or "then" steps around it.
+func (t *Translator) BuildPrimaryCommand(managerName, operation string, input CommandInput) ([]string, error) {
+	cmds, _, err := t.BuildCommands(managerName, operation, input)
 	if err != nil {
 		return nil, err
 	}
+	return cmds[t.primaryIndex(managerName, operation)], nil
+}
 
-	result := [][]string{first}
+// BuildChainCommands returns the "then" steps that follow an operation's main
+// command, along with metadata describing each of them. Both are empty for
+// operations without a chain.
+func (t *Translator) BuildChainCommands(managerName, operation string, input CommandInput) ([][]string, *ChainMetadata, error) {
+	cmds, meta, err := t.BuildCommands(managerName, operation, input)
+	if err != nil {
+		return nil, nil, err
+	}
+	i := t.primaryIndex(managerName, operation) + 1
+	return cmds[i:], &ChainMetadata{Steps: meta.Steps[i:]}, nil
+}
+
+// primaryIndex returns where an operation's main command sits in the
+// BuildCommands output, after all of its "before" steps.
+func (t *Translator) primaryIndex(managerName, operation string) int {
+	n := 0
+	for _, before := range t.definitions[managerName].Commands[operation].Before {
+		n += chainLength(before)
+	}
+	return n
+}
+
+// chainLength counts the commands a step expands to, including its own
+// before and then steps.
+func chainLength(cmd definitions.Command) int {
+	n := 1
+	for _, c := range cmd.Before {
+		n += chainLength(c)
+	}
+	for _, c := range cmd.Then {
+		n += chainLength(c)
+	}
+	return n
+}
+
+// maxChainDepth limits how deeply "then" steps may nest, so a definition
+// whose chain loops back on itself fails instead of recursing forever.
+const maxChainDepth = 10
+
+func (t *Translator) buildCommandChain(def *definitions.Definition, operation string, cmd definitions.Command, input CommandInput) ([][]string, *ChainMetadata, error) {
+	var result [][]string
+	meta := &ChainMetadata{}
+	if err := t.appendChain(def, operation, cmd, input, 0, &result, meta); err != nil {
+		return nil, nil, err
+	}
+	return result, meta, nil
+}
+
+// appendChain adds cmd's before steps, cmd itself and then the steps chained
+// after it, expanding each step's own before and then steps depth first.
+func (t *Translator) appendChain(def *definitions.Definition, operation string, cmd definitions.Command, input CommandInput, depth int, result *[][]string, meta *ChainMetadata) error {
+	if depth > maxChainDepth {
+		return ErrCircularCommandChain{Manager: def.Name, Operation: operation, Depth: depth}
+	}
+
+	for _, before := range cmd.Before {
+		if err := t.appendChain(def, operation, before, input, depth+1, result, meta); err != nil {
+			return err
+		}
+	}
+
+	binary := binaryFor(def, input)
+	built, err := t.buildSingleCommand(binary, cmd, input)
+	if err != nil {
+		return err
+	}
+	*result = append(*result, built)
+	meta.Steps = append(meta.Steps, chainStep(binary, cmd))
 
 	for _, next := range cmd.Then {
-		nextCmd, err := t.buildSingleCommand(binary, next, input)
-		if err != nil {
-			return nil, err
+		if err := t.appendChain(def, operation, next, input, depth+1, result, meta); err != nil {
+			return err
 		}
-		result = append(result, nextCmd)
 	}
+	return nil
+}
 
-	return result, nil
+func chainStep(binary string, cmd definitions.Command) ChainStep {
+	if cmd.Binary != "" {
+		binary = cmd.Binary
+	}
+	label := cmd.Label
+	if label == "" {
+		label = strings.Join(append([]string{binary}, cmd.Base...), " ")
+	}
+	return ChainStep{Label: label, Optional: cmd.Optional}
 }
 
 func (t *Translator) buildSingleCommand(binary string, cmd definitions.Command, input CommandInput) ([]string, error) {
+	if cmd.Binary != "" {
+		binary = cmd.Binary
+	}
 	args := []string{binary}
 
-	// Check for base overrides (e.g., frozen flag changes "install" to "ci" for npm)
+	// Check for base overrides (e.g., frozen flag changes "install" to "ci" for npm).
+	// An arg of the same name also selects the override, so an arg spliced
+	// into the base (yarn workspace <name> add) brings its base with it.
 	baseOverrideUsed := ""
 	base := cmd.Base
 	for flagName, override := range cmd.BaseOverrides {
-		if val, ok := input.Flags[flagName]; ok && isTruthy(val) {
+		val, ok := input.Flags[flagName]
+		if argVal, isArg := input.Args[flagName]; isArg && argVal != "" {
+			val, ok = argVal, true
+		}
+		if ok && isTruthy(val) {
 			base = override
 			baseOverrideUsed = flagName
 			break
 		}
 	}
-	args = append(args, base...)
+
+	// Fill in defaults for omitted args so every step below sees the same values
+	argVals := resolveArgs(cmd.Args, input.Args)
+
+	args = append(args, spliceBaseArgs(base, cmd.Args, argVals)...)
 
 	// Process args in a deterministic order by position
 	// First handle package, then version (for suffix handling)
 	packageVal := ""
-	if val, ok := input.Args["package"]; ok {
+	if val, ok := argVals["package"]; ok {
 		packageVal = val
 	}
 
-	// Sort args by position to ensure deterministic order
-	// Flag-style args (with argDef.Flag set) should come after positional args
-	type argEntry struct {
-		name   string
-		argDef definitions.Arg
-	}
-	var sortedArgs []argEntry
-	for name, argDef := range cmd.Args {
-		sortedArgs = append(sortedArgs, argEntry{name, argDef})
-	}
-	sort.Slice(sortedArgs, func(i, j int) bool {
-		// Flag-style args come after positional args
-		iIsFlag := sortedArgs[i].argDef.Flag != ""
-		jIsFlag := sortedArgs[j].argDef.Flag != ""
-		if iIsFlag != jIsFlag {
-			return !iIsFlag // positional args (non-flag) come first
-		}
-		// Within same category, sort by position
-		return sortedArgs[i].argDef.Position < sortedArgs[j].argDef.Position
-	})
+	// Flag-style args (with argDef.Flag set) come after positional args
+	sortedArgs := sortArgsByPosition(cmd.Args)
 
 	for _, entry := range sortedArgs {
 		name := entry.name
 		argDef := entry.argDef
-		val, provided := input.Args[name]
+		val, provided := argVals[name]
 		if !provided {
 			if argDef.Required && !argDef.ExtractionOnly {
 				return nil, ErrMissingArgument{Argument: name}
@@ -151,6 +315,11 @@ func (t *Translator) buildSingleCommand(binary string, cmd definitions.Command,
 			}
 		}
 
+		// Already spliced into the base
+		if isBaseArg(argDef) {
+			continue
+		}
+
 		if argDef.Flag != "" {
 			// Flag-style arg: --version "1.0"
 			args = append(args, argDef.Flag, val)
@@ -168,7 +337,7 @@ func (t *Translator) buildSingleCommand(binary string, cmd definitions.Command,
 
 	// Handle version suffix (append to package)
 	if versionDef, hasVersion := cmd.Args["version"]; hasVersion && versionDef.Suffix != "" {
-		if version, hasVersionVal := input.Args["version"]; hasVersionVal {
+		if version, hasVersionVal := argVals["version"]; hasVersionVal {
 			// Find and update the package arg
 			for i, a := range args {
 				if a == packageVal {
@@ -181,10 +350,22 @@ func (t *Translator) buildSingleCommand(binary string, cmd definitions.Command,
 
 	// Add default flags
 	args = append(args, cmd.DefaultFlags...)
+	for _, cf := range cmd.ConditionalFlags {
+		if os.Getenv(cf.EnvVar) != "" {
+			args = append(args, cf.Flag)
+		}
+	}
 
-	// Add user-specified flags
-	for name, val := range input.Flags {
-		if val == false || val == "" || val == nil {
+	// Add user-specified flags in name order so the same input always
+	// builds the same command
+	names := make([]string, 0, len(input.Flags))
+	for name := range input.Flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		val := input.Flags[name]
+		if !isTruthy(val) {
 			continue
 		}
 
@@ -199,38 +380,180 @@ func (t *Translator) buildSingleCommand(binary string, cmd definitions.Command,
 		}
 
 		expanded := t.expandFlag(flagDef, input.Flags)
+		// Don't repeat a flag the command already passes by default (--json)
+		if len(expanded) == 1 && slices.Contains(cmd.DefaultFlags, expanded[0]) {
+			continue
+		}
 		args = append(args, expanded...)
 	}
 
-	// Append any extra raw arguments (escape hatch for manager-specific flags)
+	// Append any extra raw arguments (escape hatch for manager-specific flags),
+	// after the separator if the command needs one and the caller didn't add it
+	if sep := cmd.ExtraSeparator; sep != "" && len(input.Extra) > 0 && input.Extra[0] != sep {
+		args = append(args, sep)
+	}
 	args = append(args, input.Extra...)
 
 	return args, nil
 }
 
+// isBaseArg reports whether an arg is placed inside the base rather than after it.
+func isBaseArg(argDef definitions.Arg) bool {
+	return argDef.Position < 0 && argDef.Flag == "" && !argDef.ExtractionOnly
+}
+
+// spliceBaseArgs inserts args with negative positions into the base, counting
+// back from its end: -1 lands before the last base token, so base [add, package]
+// with project at -1 becomes [add, <project>, package].
+func spliceBaseArgs(base []string, defs map[string]definitions.Arg, vals map[string]string) []string {
+	type spliced struct {
+		name     string
+		position int
+	}
+	var toSplice []spliced
+	for name, argDef := range defs {
+		if _, ok := vals[name]; ok && isBaseArg(argDef) {
+			toSplice = append(toSplice, spliced{name, argDef.Position})
+		}
+	}
+	if len(toSplice) == 0 {
+		return base
+	}
+	sort.Slice(toSplice, func(i, j int) bool {
+		if toSplice[i].position != toSplice[j].position {
+			return toSplice[i].position < toSplice[j].position
+		}
+		return toSplice[i].name < toSplice[j].name
+	})
+
+	result := append([]string(nil), base...)
+	for i, s := range toSplice {
+		idx := len(base) + s.position + i
+		if idx < 0 {
+			idx = 0
+		}
+		result = append(result[:idx], append([]string{vals[s.name]}, result[idx:]...)...)
+	}
+	return result
+}
+
+// argEntry pairs an arg definition with its name.
+type argEntry struct {
+	name   string
+	argDef definitions.Arg
+}
+
+// sortArgsByPosition returns the args in a deterministic order: flag-style
+// args with a negative position (conda install -c <channel> <package>), then
+// positional args, then the remaining flag-style args, each group ordered by
+// position and then by name.
+func sortArgsByPosition(defs map[string]definitions.Arg) []argEntry {
+	sorted := make([]argEntry, 0, len(defs))
+	for name, argDef := range defs {
+		sorted = append(sorted, argEntry{name, argDef})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		iGroup := argGroup(sorted[i].argDef)
+		jGroup := argGroup(sorted[j].argDef)
+		if iGroup != jGroup {
+			return iGroup < jGroup
+		}
+		if sorted[i].argDef.Position != sorted[j].argDef.Position {
+			return sorted[i].argDef.Position < sorted[j].argDef.Position
+		}
+		return sorted[i].name < sorted[j].name
+	})
+	return sorted
+}
+
+// argGroup ranks an arg for sortArgsByPosition: leading flags, positional
+// args, then trailing flags.
+func argGroup(argDef definitions.Arg) int {
+	switch {
+	case argDef.Flag != "" && argDef.Position < 0:
+		return 0
+	case argDef.Flag == "":
+		return 1
+	default:
+		return 2
+	}
+}
+
+// resolveArgs returns the caller's args with each omitted arg that declares a
+// default filled in. The caller's map is not modified.
+func resolveArgs(defs map[string]definitions.Arg, provided map[string]string) map[string]string {
+	vals := make(map[string]string, len(provided))
+	for name, val := range provided {
+		vals[name] = val
+	}
+	for name, argDef := range defs {
+		if _, ok := vals[name]; !ok && argDef.Default != "" {
+			vals[name] = argDef.Default
+		}
+	}
+	return vals
+}
+
 func (t *Translator) expandFlag(flag definitions.Flag, flags map[string]any) []string {
 	var result []string
 	for _, v := range flag.Values {
-		if v.Literal != "" && v.Field != "" && v.Join != "" {
-			// Joined flag: --group=development
-			if val, ok := flags[v.Field]; ok {
-				if s, ok := val.(string); ok && s != "" {
-					result = append(result, v.Literal+v.Join+s)
+		if v.SliceField != "" {
+			// Repeated flag: --os=linux --os=darwin, or --with docs --with test
+			for _, item := range flagItems(flags[v.SliceField]) {
+				if v.EachFlag != "" {
+					result = append(result, v.EachFlag)
 				}
+				result = append(result, v.Literal+item)
+			}
+		} else if v.Literal != "" && v.Field != "" && v.Join != "" {
+			// Joined flag: --group=development
+			if s := flagString(flags[v.Field], v.Separator); s != "" {
+				result = append(result, v.Literal+v.Join+s)
 			}
 		} else if v.Literal != "" {
 			result = append(result, v.Literal)
 		} else if v.Field != "" {
-			if val, ok := flags[v.Field]; ok {
-				if s, ok := val.(string); ok && s != "" {
-					result = append(result, s)
-				}
+			if s := flagString(flags[v.Field], v.Separator); s != "" {
+				result = append(result, s)
 			}
 		}
 	}
 	return result
 }
 
+// flagItems returns the items of a list flag value. A single string counts
+// as a one-item list.
+func flagItems(val any) []string {
+	switch v := val.(type) {
+	case string:
+		if v == "" {
+			return nil
+		}
+		return []string{v}
+	case []string:
+		return v
+	default:
+		return nil
+	}
+}
+
+// flagString returns the text a flag value contributes to a command. Lists
+// are joined with sep, or commas if it is empty (--with docs,test); other
+// types contribute nothing.
+func flagString(val any, sep string) string {
+	if sep == "" {
+		sep = ","
+	}
+	switch v := val.(type) {
+	case string:
+		return v
+	case []string:
+		return strings.Join(v, sep)
+	default:
+		return ""
+	}
+}
+
 func (t *Translator) validate(validatorName, value string) error {
 	v, ok := t.validators[validatorName]
 	if !ok {
@@ -256,6 +579,8 @@ func isTruthy(val any) bool {
 		return v
 	case string:
 		return v != ""
+	case []string:
+		return len(v) > 0
 	default:
 		return true
 	}
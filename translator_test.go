@@ -1,7 +1,9 @@
 package managers
 
 import (
+	"errors"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/git-pkgs/managers/definitions"
@@ -49,6 +51,153 @@ func TestNpmInstallFrozen(t *testing.T) {
 	}
 }
 
+func TestNpmInstallIgnoreScripts(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("npm", "install", CommandInput{
+		Flags: map[string]any{"ignore_scripts": true},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"npm", "install", "--ignore-scripts"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestNpmInstallIncludePlatforms(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("npm", "install", CommandInput{
+		Flags: map[string]any{"include_os": []string{"linux"}, "include_cpu": []string{"x64"}},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"npm", "install", "--cpu=x64", "--os=linux"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+
+	cmd, err = tr.BuildCommand("pnpm", "install", CommandInput{
+		Flags: map[string]any{"include_os": []string{"darwin"}},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected = []string{"pnpm", "install", "--os=darwin"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestListFlagJoinAndRepeat(t *testing.T) {
+	tr := NewTranslator()
+	tr.Register(&definitions.Definition{
+		Name:   "testpkg",
+		Binary: "testpkg",
+		Commands: map[string]definitions.Command{
+			"install": {
+				Base: []string{"install"},
+				Flags: map[string]definitions.Flag{
+					"with":     {Values: []definitions.FlagValue{{Literal: "--with"}, {Field: "with"}}},
+					"features": {Values: []definitions.FlagValue{{Literal: "--features"}, {Field: "features", Separator: " "}}},
+					"extras":   {Values: []definitions.FlagValue{{SliceField: "extras", EachFlag: "--extras"}}},
+				},
+			},
+		},
+	})
+
+	tests := []struct {
+		name     string
+		flag     string
+		expected []string
+	}{
+		{"default comma join", "with", []string{"testpkg", "install", "--with", "docs,test"}},
+		{"custom separator", "features", []string{"testpkg", "install", "--features", "docs test"}},
+		{"repeated flag", "extras", []string{"testpkg", "install", "--extras", "docs", "--extras", "test"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := tr.BuildCommand("testpkg", "install", CommandInput{
+				Flags: map[string]any{tt.flag: []string{"docs", "test"}},
+			})
+			if err != nil {
+				t.Fatalf("BuildCommand failed: %v", err)
+			}
+			if !reflect.DeepEqual(cmd, tt.expected) {
+				t.Errorf("got %v, want %v", cmd, tt.expected)
+			}
+		})
+	}
+}
+
+func TestConditionalFlags(t *testing.T) {
+	tr := NewTranslator()
+	tr.Register(&definitions.Definition{
+		Name:   "testpkg",
+		Binary: "testpkg",
+		Commands: map[string]definitions.Command{
+			"install": {
+				Base:             []string{"install"},
+				DefaultFlags:     []string{"--quiet"},
+				ConditionalFlags: []definitions.ConditionalFlag{{EnvVar: "TESTPKG_NONINTERACTIVE", Flag: "--yes"}},
+			},
+		},
+	})
+
+	tests := []struct {
+		name     string
+		env      string
+		expected []string
+	}{
+		{"unset", "", []string{"testpkg", "install", "--quiet"}},
+		{"set", "1", []string{"testpkg", "install", "--quiet", "--yes"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TESTPKG_NONINTERACTIVE", tt.env)
+			cmd, err := tr.BuildCommand("testpkg", "install", CommandInput{})
+			if err != nil {
+				t.Fatalf("BuildCommand failed: %v", err)
+			}
+			if !reflect.DeepEqual(cmd, tt.expected) {
+				t.Errorf("got %v, want %v", cmd, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNpmAddIgnoreScripts(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("npm", "add", CommandInput{
+		Args:  map[string]string{"package": "lodash"},
+		Flags: map[string]any{"ignore_scripts": true},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"npm", "install", "lodash", "--ignore-scripts"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestNpmInstallWorkspaces(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("npm", "install", CommandInput{
+		Flags: map[string]any{"workspaces": true},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"npm", "install", "--workspaces"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
 func TestNpmAdd(t *testing.T) {
 	tr := loadTranslator(t)
 	cmd, err := tr.BuildCommand("npm", "add", CommandInput{
@@ -78,6 +227,36 @@ func TestNpmAddDev(t *testing.T) {
 	}
 }
 
+func TestNpmAddPeer(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("npm", "add", CommandInput{
+		Args:  map[string]string{"package": "lodash"},
+		Flags: map[string]any{"peer": true},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"npm", "install", "lodash", "--save-peer"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestNpmAddToWorkspace(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("npm", "add", CommandInput{
+		Args:  map[string]string{"package": "lodash"},
+		Flags: map[string]any{"workspace": "web"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"npm", "install", "lodash", "--workspace", "web"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
 func TestNpmAddVersion(t *testing.T) {
 	tr := loadTranslator(t)
 	cmd, err := tr.BuildCommand("npm", "add", CommandInput{
@@ -107,6 +286,20 @@ func TestNpmList(t *testing.T) {
 	}
 }
 
+func TestNpmListJSONNotRepeated(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("npm", "list", CommandInput{
+		Flags: map[string]any{"json": true, "depth": "1"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"npm", "list", "--json", "--depth", "1"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
 func TestNpmRemove(t *testing.T) {
 	tr := loadTranslator(t)
 	cmd, err := tr.BuildCommand("npm", "remove", CommandInput{
@@ -247,6 +440,20 @@ func TestBundlerList(t *testing.T) {
 	}
 }
 
+func TestBundlerExec(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("bundler", "exec", CommandInput{
+		Args: map[string]string{"command": "rspec"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"bundle", "exec", "rspec"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
 func TestBundlerOutdated(t *testing.T) {
 	tr := loadTranslator(t)
 	cmd, err := tr.BuildCommand("bundler", "outdated", CommandInput{})
@@ -330,6 +537,38 @@ func TestCargoAddDev(t *testing.T) {
 	}
 }
 
+func TestCargoAddFeatures(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("cargo", "add", CommandInput{
+		Args:  map[string]string{"package": "serde"},
+		Flags: map[string]any{"features": "derive"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"cargo", "add", "serde", "--features", "derive"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestCargoAddGit(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("cargo", "add", CommandInput{
+		Args: map[string]string{
+			"package": "my-crate",
+			"git":     "https://github.com/user/my-crate",
+		},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"cargo", "add", "my-crate", "--git", "https://github.com/user/my-crate"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
 func TestCargoRemove(t *testing.T) {
 	tr := loadTranslator(t)
 	cmd, err := tr.BuildCommand("cargo", "remove", CommandInput{
@@ -350,7 +589,7 @@ func TestCargoList(t *testing.T) {
 	if err != nil {
 		t.Fatalf("BuildCommand failed: %v", err)
 	}
-	expected := []string{"cargo", "tree"}
+	expected := []string{"cargo", "metadata", "--format-version", "1"}
 	if !reflect.DeepEqual(cmd, expected) {
 		t.Errorf("got %v, want %v", cmd, expected)
 	}
@@ -400,7 +639,7 @@ func TestGomodAdd(t *testing.T) {
 
 func TestGomodAddChain(t *testing.T) {
 	tr := loadTranslator(t)
-	cmds, err := tr.BuildCommands("gomod", "add", CommandInput{
+	cmds, meta, err := tr.BuildCommands("gomod", "add", CommandInput{
 		Args: map[string]string{"package": "github.com/pkg/errors"},
 	})
 	if err != nil {
@@ -417,6 +656,173 @@ func TestGomodAddChain(t *testing.T) {
 	if !reflect.DeepEqual(cmds[1], expected2) {
 		t.Errorf("cmd[1]: got %v, want %v", cmds[1], expected2)
 	}
+
+	expectedSteps := []ChainStep{{Label: "go get"}, {Label: "go mod tidy"}}
+	if !reflect.DeepEqual(meta.Steps, expectedSteps) {
+		t.Errorf("steps: got %v, want %v", meta.Steps, expectedSteps)
+	}
+}
+
+func TestBuildCommandsChainLabels(t *testing.T) {
+	tr := NewTranslator()
+	tr.Register(&definitions.Definition{
+		Name:   "testpkg",
+		Binary: "testpkg",
+		Commands: map[string]definitions.Command{
+			"add": {
+				Base:  []string{"add"},
+				Label: "add package",
+				Then: []definitions.Command{
+					{Base: []string{"lock"}},
+					{Base: []string{"audit"}, Label: "security audit", Optional: true},
+				},
+			},
+		},
+	})
+
+	cmds, meta, err := tr.BuildCommands("testpkg", "add", CommandInput{})
+	if err != nil {
+		t.Fatalf("BuildCommands failed: %v", err)
+	}
+	if len(meta.Steps) != len(cmds) {
+		t.Fatalf("got %d steps for %d commands", len(meta.Steps), len(cmds))
+	}
+	expected := []ChainStep{
+		{Label: "add package"},
+		{Label: "testpkg lock"},
+		{Label: "security audit", Optional: true},
+	}
+	if !reflect.DeepEqual(meta.Steps, expected) {
+		t.Errorf("got %v, want %v", meta.Steps, expected)
+	}
+}
+
+func TestBuildPrimaryAndChainCommands(t *testing.T) {
+	tr := loadTranslator(t)
+	input := CommandInput{
+		Args: map[string]string{"package": "github.com/pkg/errors"},
+	}
+
+	primary, err := tr.BuildPrimaryCommand("gomod", "add", input)
+	if err != nil {
+		t.Fatalf("BuildPrimaryCommand failed: %v", err)
+	}
+	expected := []string{"go", "get", "github.com/pkg/errors"}
+	if !reflect.DeepEqual(primary, expected) {
+		t.Errorf("primary: got %v, want %v", primary, expected)
+	}
+
+	chain, meta, err := tr.BuildChainCommands("gomod", "add", input)
+	if err != nil {
+		t.Fatalf("BuildChainCommands failed: %v", err)
+	}
+	expectedChain := [][]string{{"go", "mod", "tidy"}}
+	if !reflect.DeepEqual(chain, expectedChain) {
+		t.Errorf("chain: got %v, want %v", chain, expectedChain)
+	}
+	expectedSteps := []ChainStep{{Label: "go mod tidy"}}
+	if !reflect.DeepEqual(meta.Steps, expectedSteps) {
+		t.Errorf("steps: got %v, want %v", meta.Steps, expectedSteps)
+	}
+
+	// Operations without a chain have an empty tail
+	chain, meta, err = tr.BuildChainCommands("gomod", "remove", input)
+	if err != nil {
+		t.Fatalf("BuildChainCommands failed: %v", err)
+	}
+	if len(chain) != 0 || len(meta.Steps) != 0 {
+		t.Errorf("expected empty chain, got %v %v", chain, meta.Steps)
+	}
+
+	if _, err := tr.BuildPrimaryCommand("nonexistent", "add", input); err == nil {
+		t.Error("expected error for unknown manager")
+	}
+}
+
+func TestBinaryOverride(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("pip", "install", CommandInput{BinaryOverride: "python3.11"})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	if cmd[0] != "python3.11" {
+		t.Errorf("got binary %q, want python3.11 in %v", cmd[0], cmd)
+	}
+
+	// Every step of a chain uses the override, except steps with their own binary
+	cmds, meta, err := tr.BuildCommands("apt", "install", CommandInput{
+		BinaryOverride: "/usr/local/bin/apt-get",
+	})
+	if err != nil {
+		t.Fatalf("BuildCommands failed: %v", err)
+	}
+	if len(cmds) != 2 {
+		t.Fatalf("expected update and install steps, got %v", cmds)
+	}
+	for i, c := range cmds {
+		if c[0] != "/usr/local/bin/apt-get" {
+			t.Errorf("step %d (%s): got binary %q", i, meta.Steps[i].Label, c[0])
+		}
+	}
+
+	cmd, err = tr.BuildCommand("apt", "list", CommandInput{BinaryOverride: "/usr/local/bin/apt-get"})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	if cmd[0] != "apt" {
+		t.Errorf("got binary %q, want apt to keep its own binary", cmd[0])
+	}
+}
+
+func TestBuildCommandsNestedChain(t *testing.T) {
+	tr := NewTranslator()
+	tr.Register(&definitions.Definition{
+		Name:   "testpkg",
+		Binary: "testpkg",
+		Commands: map[string]definitions.Command{
+			"add": {
+				Base: []string{"add"},
+				Then: []definitions.Command{
+					{Base: []string{"lock"}, Then: []definitions.Command{{Base: []string{"verify"}}}},
+					{Base: []string{"audit"}},
+				},
+			},
+		},
+	})
+
+	cmds, _, err := tr.BuildCommands("testpkg", "add", CommandInput{})
+	if err != nil {
+		t.Fatalf("BuildCommands failed: %v", err)
+	}
+	expected := [][]string{{"testpkg", "add"}, {"testpkg", "lock"}, {"testpkg", "verify"}, {"testpkg", "audit"}}
+	if !reflect.DeepEqual(cmds, expected) {
+		t.Errorf("got %v, want %v", cmds, expected)
+	}
+}
+
+func TestBuildCommandsCircularChain(t *testing.T) {
+	// Build a chain deeper than any real definition, as a looping chain
+	// would be once expanded
+	cmd := definitions.Command{Base: []string{"step"}}
+	for i := 0; i < maxChainDepth+1; i++ {
+		cmd = definitions.Command{Base: []string{"step"}, Then: []definitions.Command{cmd}}
+	}
+
+	tr := NewTranslator()
+	tr.Register(&definitions.Definition{
+		Name:     "testpkg",
+		Binary:   "testpkg",
+		Commands: map[string]definitions.Command{"add": cmd},
+	})
+
+	_, _, err := tr.BuildCommands("testpkg", "add", CommandInput{})
+	var circular ErrCircularCommandChain
+	if !errors.As(err, &circular) {
+		t.Fatalf("expected ErrCircularCommandChain, got %v", err)
+	}
+	if circular.Manager != "testpkg" || circular.Operation != "add" || circular.Depth != maxChainDepth+1 {
+		t.Errorf("unexpected error fields: %+v", circular)
+	}
 }
 
 func TestGomodRemove(t *testing.T) {
@@ -492,9 +898,12 @@ func TestUnknownManager(t *testing.T) {
 func TestUnsupportedOperation(t *testing.T) {
 	tr := loadTranslator(t)
 	_, err := tr.BuildCommand("npm", "unknown_operation", CommandInput{})
-	if err != ErrUnsupportedOperation {
+	if !errors.Is(err, ErrUnsupportedOperation) {
 		t.Errorf("expected ErrUnsupportedOperation, got %v", err)
 	}
+	if !strings.Contains(err.Error(), "see https://docs.npmjs.com/cli") {
+		t.Errorf("expected error to point at the npm docs, got %q", err)
+	}
 }
 
 // --- pnpm tests ---
@@ -554,6 +963,36 @@ func TestPnpmAddDev(t *testing.T) {
 	}
 }
 
+func TestPnpmAddToWorkspace(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("pnpm", "add", CommandInput{
+		Args:  map[string]string{"package": "lodash"},
+		Flags: map[string]any{"workspace": "web"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"pnpm", "add", "lodash", "--filter", "web"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestPnpmAddPeer(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("pnpm", "add", CommandInput{
+		Args:  map[string]string{"package": "react"},
+		Flags: map[string]any{"peer": true},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"pnpm", "add", "react", "--save-peer"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
 func TestPnpmRemove(t *testing.T) {
 	tr := loadTranslator(t)
 	cmd, err := tr.BuildCommand("pnpm", "remove", CommandInput{
@@ -648,6 +1087,21 @@ func TestUvAdd(t *testing.T) {
 	}
 }
 
+func TestUvAddGroup(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("uv", "add", CommandInput{
+		Args:  map[string]string{"package": "pytest"},
+		Flags: map[string]any{"group": "test"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"uv", "add", "pytest", "--group", "test"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
 func TestUvAddDev(t *testing.T) {
 	tr := loadTranslator(t)
 	cmd, err := tr.BuildCommand("uv", "add", CommandInput{
@@ -755,16 +1209,45 @@ func TestYarnAdd(t *testing.T) {
 	}
 }
 
-func TestYarnAddDev(t *testing.T) {
+func TestYarnBerryAddToWorkspace(t *testing.T) {
 	tr := loadTranslator(t)
 	cmd, err := tr.BuildCommand("yarn", "add", CommandInput{
-		Args:  map[string]string{"package": "lodash"},
-		Flags: map[string]any{"dev": true},
+		Args:  map[string]string{"package": "lodash", "workspace": "web"},
+		Flags: map[string]any{"workspace": "web"},
 	})
 	if err != nil {
 		t.Fatalf("BuildCommand failed: %v", err)
 	}
-	expected := []string{"yarn", "add", "lodash", "--dev"}
+	expected := []string{"yarn", "workspace", "web", "add", "lodash"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestYarnBerryAddToWorkspaceArgOnly(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("yarn", "add", CommandInput{
+		Args: map[string]string{"package": "lodash", "workspace": "web"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"yarn", "workspace", "web", "add", "lodash"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestYarnAddDev(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("yarn", "add", CommandInput{
+		Args:  map[string]string{"package": "lodash"},
+		Flags: map[string]any{"dev": true},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"yarn", "add", "lodash", "--dev"}
 	if !reflect.DeepEqual(cmd, expected) {
 		t.Errorf("got %v, want %v", cmd, expected)
 	}
@@ -974,6 +1457,32 @@ func TestPoetryInstallProduction(t *testing.T) {
 	}
 }
 
+func TestPoetryInstallWithGroups(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("poetry", "install", CommandInput{
+		Flags: map[string]any{"include_groups": []string{"docs", "test"}},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"poetry", "install", "--with", "docs,test"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+
+	// An empty list adds nothing, not a dangling --with
+	cmd, err = tr.BuildCommand("poetry", "install", CommandInput{
+		Flags: map[string]any{"include_groups": []string{}},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected = []string{"poetry", "install"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
 func TestPoetryAdd(t *testing.T) {
 	tr := loadTranslator(t)
 	cmd, err := tr.BuildCommand("poetry", "add", CommandInput{
@@ -1029,6 +1538,20 @@ func TestPoetryList(t *testing.T) {
 	}
 }
 
+func TestPoetryListTree(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("poetry", "list", CommandInput{
+		Flags: map[string]any{"tree": true},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"poetry", "show", "--tree"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
 func TestPoetryOutdated(t *testing.T) {
 	tr := loadTranslator(t)
 	cmd, err := tr.BuildCommand("poetry", "outdated", CommandInput{})
@@ -1055,6 +1578,21 @@ func TestPoetryUpdate(t *testing.T) {
 	}
 }
 
+func TestPoetryUpdateDryRun(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("poetry", "update", CommandInput{
+		Args:  map[string]string{"package": "requests"},
+		Flags: map[string]any{"dry_run": true},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"poetry", "update", "requests", "--dry-run"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
 // --- mix tests ---
 
 func TestMixInstall(t *testing.T) {
@@ -1135,6 +1673,34 @@ func TestMixUpdateAll(t *testing.T) {
 	}
 }
 
+func TestMixUnlock(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("mix", "unlock", CommandInput{
+		Args: map[string]string{"package": "phoenix"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"mix", "deps.unlock", "phoenix"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestMixUnlockAll(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("mix", "unlock", CommandInput{
+		Flags: map[string]any{"all": true},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"mix", "deps.unlock", "--all"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
 // --- pub tests ---
 
 func TestPubInstall(t *testing.T) {
@@ -1297,6 +1863,21 @@ func TestCocoapodsUpdate(t *testing.T) {
 	}
 }
 
+func TestCocoapodsAddUnsupported(t *testing.T) {
+	tr := loadTranslator(t)
+	for _, op := range []string{"add", "remove"} {
+		_, err := tr.BuildCommand("cocoapods", op, CommandInput{
+			Args: map[string]string{"package": "Alamofire"},
+		})
+		if !errors.Is(err, ErrUnsupportedOperation) {
+			t.Fatalf("%s: expected ErrUnsupportedOperation, got %v", op, err)
+		}
+		if !strings.Contains(err.Error(), "Podfile") {
+			t.Errorf("%s: expected a hint about the Podfile, got %q", op, err)
+		}
+	}
+}
+
 // --- bun tests ---
 
 func TestBunInstall(t *testing.T) {
@@ -1368,6 +1949,21 @@ func TestBunAddDev(t *testing.T) {
 	}
 }
 
+func TestBunAddPeer(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("bun", "add", CommandInput{
+		Args:  map[string]string{"package": "react"},
+		Flags: map[string]any{"peer": true},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"bun", "add", "react", "--peer"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
 func TestBunRemove(t *testing.T) {
 	tr := loadTranslator(t)
 	cmd, err := tr.BuildCommand("bun", "remove", CommandInput{
@@ -1388,7 +1984,8 @@ func TestBunList(t *testing.T) {
 	if err != nil {
 		t.Fatalf("BuildCommand failed: %v", err)
 	}
-	expected := []string{"bun", "pm", "list"}
+	// "bun pm ls" is preferred since bun 1.1; "list" is an alias for it
+	expected := []string{"bun", "pm", "ls"}
 	if !reflect.DeepEqual(cmd, expected) {
 		t.Errorf("got %v, want %v", cmd, expected)
 	}
@@ -1510,6 +2107,154 @@ func TestExtraArgsGomod(t *testing.T) {
 	}
 }
 
+func safeExtraTranslator() *Translator {
+	tr := NewTranslator()
+	tr.Register(&definitions.Definition{
+		Name:   "cargo",
+		Binary: "cargo",
+		Commands: map[string]definitions.Command{
+			"add": {
+				Base: []string{"add"},
+				Args: map[string]definitions.Arg{
+					"package": {Position: 0, Required: true},
+				},
+			},
+			"install": {Base: []string{"fetch"}},
+		},
+		SafeExtraArgs: map[string][]string{
+			"add": {"--features", "--locked"},
+		},
+	})
+	return tr
+}
+
+func TestSafeExtraArgs(t *testing.T) {
+	tr := safeExtraTranslator()
+
+	tests := []struct {
+		name    string
+		extra   []string
+		wantErr bool
+	}{
+		{"no extras", nil, false},
+		{"allowed flag", []string{"--locked"}, false},
+		{"allowed flag with value", []string{"--features", "derive"}, false},
+		{"allowed flag with joined value", []string{"--features=derive"}, false},
+		{"unknown flag", []string{"--config", "evil.toml"}, true},
+		{"stray value", []string{"--locked", "derive", "other"}, true},
+		{"positional", []string{"other-crate"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tr.BuildCommand("cargo", "add", CommandInput{
+				Args:  map[string]string{"package": "serde"},
+				Extra: tt.extra,
+			})
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnsupportedOption) {
+					t.Errorf("expected ErrUnsupportedOption, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestSafeExtraArgsOnlyApplyToListedOperations(t *testing.T) {
+	tr := safeExtraTranslator()
+	cmd, err := tr.BuildCommand("cargo", "install", CommandInput{
+		Extra: []string{"--config", "custom.toml"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"cargo", "fetch", "--config", "custom.toml"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+// --- arg default tests ---
+
+func defaultArgsTranslator() *Translator {
+	tr := NewTranslator()
+	tr.Register(&definitions.Definition{
+		Name:   "testpkg",
+		Binary: "testpkg",
+		Commands: map[string]definitions.Command{
+			"list": {
+				Base: []string{"list"},
+				Args: map[string]definitions.Arg{
+					"depth": {Flag: "--depth", Default: "0"},
+				},
+			},
+			"add": {
+				Base: []string{"add"},
+				Args: map[string]definitions.Arg{
+					"package": {Position: 0, Required: true},
+					"version": {Suffix: "@", Default: "latest"},
+				},
+			},
+		},
+	})
+	return tr
+}
+
+func TestArgDefaultUsedWhenOmitted(t *testing.T) {
+	tr := defaultArgsTranslator()
+	cmd, err := tr.BuildCommand("testpkg", "list", CommandInput{})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"testpkg", "list", "--depth", "0"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestArgDefaultOverridden(t *testing.T) {
+	tr := defaultArgsTranslator()
+	cmd, err := tr.BuildCommand("testpkg", "list", CommandInput{
+		Args: map[string]string{"depth": "3"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"testpkg", "list", "--depth", "3"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestArgDefaultVersionSuffix(t *testing.T) {
+	tr := defaultArgsTranslator()
+	cmd, err := tr.BuildCommand("testpkg", "add", CommandInput{
+		Args: map[string]string{"package": "lodash"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"testpkg", "add", "lodash@latest"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestArgDefaultDoesNotMutateInput(t *testing.T) {
+	tr := defaultArgsTranslator()
+	args := map[string]string{}
+	if _, err := tr.BuildCommand("testpkg", "list", CommandInput{Args: args}); err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	if len(args) != 0 {
+		t.Errorf("expected input args to be untouched, got %v", args)
+	}
+}
+
 // --- maven tests ---
 
 func TestMavenInstall(t *testing.T) {
@@ -1586,6 +2331,51 @@ func TestGradleList(t *testing.T) {
 	}
 }
 
+func TestGradleOutdated(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("gradle", "outdated", CommandInput{})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"gradle", "dependencyUpdates", "--info"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+
+	if !NewGenericManager(embeddedDefinition(t, "gradle"), "/test/project").Supports(CapOutdated) {
+		t.Error("expected gradle to list outdated as a capability")
+	}
+}
+
+func TestGradleAddUnsupported(t *testing.T) {
+	tr := loadTranslator(t)
+	for _, op := range []string{"add", "remove"} {
+		_, err := tr.BuildCommand("gradle", op, CommandInput{
+			Args: map[string]string{"package": "com.google.guava:guava"},
+		})
+		if !errors.Is(err, ErrUnsupportedOperation) {
+			t.Fatalf("%s: expected ErrUnsupportedOperation, got %v", op, err)
+		}
+		if !strings.Contains(err.Error(), "build.gradle") {
+			t.Errorf("%s: expected a hint about build.gradle, got %q", op, err)
+		}
+	}
+}
+
+func TestGradleVendor(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("gradle", "vendor", CommandInput{
+		Flags: map[string]any{"gradle_version": "8.7"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"gradle", "wrapper", "--gradle-version", "8.7"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
 // --- nuget tests ---
 
 func TestNugetInstall(t *testing.T) {
@@ -1628,6 +2418,23 @@ func TestNugetAdd(t *testing.T) {
 	}
 }
 
+func TestNugetAddProject(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("nuget", "add", CommandInput{
+		Args: map[string]string{
+			"package": "Newtonsoft.Json",
+			"project": "MyProject/MyProject.csproj",
+		},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"dotnet", "add", "MyProject/MyProject.csproj", "package", "Newtonsoft.Json"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
 func TestNugetAddVersion(t *testing.T) {
 	tr := loadTranslator(t)
 	cmd, err := tr.BuildCommand("nuget", "add", CommandInput{
@@ -1882,6 +2689,20 @@ func TestCondaAdd(t *testing.T) {
 	}
 }
 
+func TestCondaAddChannel(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("conda", "add", CommandInput{
+		Args: map[string]string{"package": "numpy", "channel": "conda-forge"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"conda", "install", "--yes", "-c", "conda-forge", "numpy"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
 func TestCondaRemove(t *testing.T) {
 	tr := loadTranslator(t)
 	cmd, err := tr.BuildCommand("conda", "remove", CommandInput{
@@ -1948,6 +2769,20 @@ func TestPipInstall(t *testing.T) {
 	}
 }
 
+func TestPipInstallRequirementsFile(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("pip", "install", CommandInput{
+		Args: map[string]string{"requirements_file": "requirements/base.txt"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"pip", "install", "-r", "requirements/base.txt"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
 func TestPipAdd(t *testing.T) {
 	tr := loadTranslator(t)
 	cmd, err := tr.BuildCommand("pip", "add", CommandInput{
@@ -2014,6 +2849,20 @@ func TestPipUpdate(t *testing.T) {
 	}
 }
 
+func TestPipVendorRequirementsFile(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("pip", "vendor", CommandInput{
+		Args: map[string]string{"requirements_file": "requirements-dev.txt"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"pip", "download", "-r", "requirements-dev.txt", "-d", "vendor"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
 // --- gem tests ---
 
 func TestGemInstall(t *testing.T) {
@@ -2057,6 +2906,36 @@ func TestGemAddVersion(t *testing.T) {
 	}
 }
 
+func TestGemAddUserInstall(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("gem", "add", CommandInput{
+		Args:  map[string]string{"package": "nokogiri"},
+		Flags: map[string]any{"user_install": true},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"gem", "install", "nokogiri", "--user-install"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestGemAddUserInstallWithVersion(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("gem", "add", CommandInput{
+		Args:  map[string]string{"package": "nokogiri", "version": "1.15.0"},
+		Flags: map[string]any{"user_install": true},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"gem", "install", "nokogiri", "--version", "1.15.0", "--user-install"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
 func TestGemRemove(t *testing.T) {
 	tr := loadTranslator(t)
 	cmd, err := tr.BuildCommand("gem", "remove", CommandInput{
@@ -2123,25 +3002,53 @@ func TestBrewInstall(t *testing.T) {
 	}
 }
 
-func TestBrewAdd(t *testing.T) {
+func TestBrewInstallCustomBrewfile(t *testing.T) {
 	tr := loadTranslator(t)
-	cmd, err := tr.BuildCommand("brew", "add", CommandInput{
-		Args: map[string]string{"package": "jq"},
+	cmd, err := tr.BuildCommand("brew", "install", CommandInput{
+		Args: map[string]string{"brewfile_path": "config/Brewfile"},
 	})
 	if err != nil {
 		t.Fatalf("BuildCommand failed: %v", err)
 	}
-	expected := []string{"brew", "install", "jq"}
+	expected := []string{"brew", "bundle", "install", "--file", "config/Brewfile"}
 	if !reflect.DeepEqual(cmd, expected) {
 		t.Errorf("got %v, want %v", cmd, expected)
 	}
 }
 
-func TestBrewAddCask(t *testing.T) {
+func TestBrewInstallNoUpgrade(t *testing.T) {
 	tr := loadTranslator(t)
-	cmd, err := tr.BuildCommand("brew", "add", CommandInput{
-		Args:  map[string]string{"package": "visual-studio-code"},
-		Flags: map[string]any{"cask": true},
+	cmd, err := tr.BuildCommand("brew", "install", CommandInput{
+		Flags: map[string]any{"no_upgrade": true},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"brew", "bundle", "install", "--no-upgrade"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestBrewAdd(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("brew", "add", CommandInput{
+		Args: map[string]string{"package": "jq"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"brew", "install", "jq"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestBrewAddCask(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("brew", "add", CommandInput{
+		Args:  map[string]string{"package": "visual-studio-code"},
+		Flags: map[string]any{"cask": true},
 	})
 	if err != nil {
 		t.Fatalf("BuildCommand failed: %v", err)
@@ -2166,6 +3073,21 @@ func TestBrewRemove(t *testing.T) {
 	}
 }
 
+func TestBrewRemoveZap(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("brew", "remove", CommandInput{
+		Args:  map[string]string{"package": "visual-studio-code"},
+		Flags: map[string]any{"zap": true},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"brew", "uninstall", "visual-studio-code", "--zap"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
 func TestBrewList(t *testing.T) {
 	tr := loadTranslator(t)
 	cmd, err := tr.BuildCommand("brew", "list", CommandInput{})
@@ -2235,6 +3157,32 @@ func TestHelmAdd(t *testing.T) {
 	}
 }
 
+func TestHelmChartInstall(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("helm", "chart_install", CommandInput{
+		Args: map[string]string{
+			"package": "nginx",
+			"chart":   "bitnami/nginx",
+			"version": "15.1.0",
+		},
+		Flags: map[string]any{"namespace": "web"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"helm", "install", "nginx", "bitnami/nginx", "--version", "15.1.0", "--namespace", "web"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+
+	// The release name alone is not enough; helm needs the chart reference
+	if _, err := tr.BuildCommand("helm", "chart_install", CommandInput{
+		Args: map[string]string{"package": "nginx"},
+	}); err == nil {
+		t.Error("expected error when chart is missing")
+	}
+}
+
 func TestHelmRemove(t *testing.T) {
 	tr := loadTranslator(t)
 	cmd, err := tr.BuildCommand("helm", "remove", CommandInput{
@@ -2261,6 +3209,18 @@ func TestHelmList(t *testing.T) {
 	}
 }
 
+func TestHelmOutdated(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("helm", "outdated", CommandInput{})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"helm", "dependency", "list"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
 func TestHelmUpdate(t *testing.T) {
 	tr := loadTranslator(t)
 	cmd, err := tr.BuildCommand("helm", "update", CommandInput{})
@@ -2337,6 +3297,21 @@ func TestRebar3List(t *testing.T) {
 	}
 }
 
+func TestRebar3AddUnsupported(t *testing.T) {
+	tr := loadTranslator(t)
+	for _, op := range []string{"add", "remove"} {
+		_, err := tr.BuildCommand("rebar3", op, CommandInput{
+			Args: map[string]string{"package": "cowboy"},
+		})
+		if !errors.Is(err, ErrUnsupportedOperation) {
+			t.Fatalf("%s: expected ErrUnsupportedOperation, got %v", op, err)
+		}
+		if !strings.Contains(err.Error(), "rebar.config") {
+			t.Errorf("%s: expected a hint about rebar.config, got %q", op, err)
+		}
+	}
+}
+
 func TestRebar3Outdated(t *testing.T) {
 	tr := loadTranslator(t)
 	cmd, err := tr.BuildCommand("rebar3", "outdated", CommandInput{})
@@ -2427,6 +3402,20 @@ func TestStackInstall(t *testing.T) {
 	}
 }
 
+func TestStackAdd(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("stack", "add", CommandInput{
+		Args: map[string]string{"package": "wai"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"stack", "install", "wai"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
 func TestStackList(t *testing.T) {
 	tr := loadTranslator(t)
 	cmd, err := tr.BuildCommand("stack", "list", CommandInput{})
@@ -2543,6 +3532,46 @@ func TestOpamUpdate(t *testing.T) {
 	}
 }
 
+func TestOpamDevelop(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("opam", "develop", CommandInput{
+		Flags: map[string]any{"yes": true},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"opam", "pin", "add", ".", "-y"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestOpamSwitchCreate(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("opam", "switch_create", CommandInput{
+		Args: map[string]string{"compiler": "4.14.0"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"opam", "switch", "create", "4.14.0"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestOpamSwitchList(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("opam", "switch_list", CommandInput{})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"opam", "switch", "list"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
 // --- vcpkg tests ---
 
 func TestVcpkgInstall(t *testing.T) {
@@ -2571,6 +3600,34 @@ func TestVcpkgAdd(t *testing.T) {
 	}
 }
 
+func TestVcpkgInstallTriplet(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("vcpkg", "install", CommandInput{
+		Args: map[string]string{"triplet": "arm64-linux"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"vcpkg", "install", "--triplet", "arm64-linux"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestVcpkgAddTriplet(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("vcpkg", "add", CommandInput{
+		Args: map[string]string{"package": "fmt", "triplet": "x64-windows"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"vcpkg", "add", "port", "fmt", "--triplet", "x64-windows"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
 func TestVcpkgRemove(t *testing.T) {
 	tr := loadTranslator(t)
 	cmd, err := tr.BuildCommand("vcpkg", "remove", CommandInput{
@@ -2635,6 +3692,17 @@ func TestConanInstall(t *testing.T) {
 	if !reflect.DeepEqual(cmd, expected) {
 		t.Errorf("got %v, want %v", cmd, expected)
 	}
+
+	cmd, err = tr.BuildCommand("conan", "install", CommandInput{
+		Flags: map[string]any{"build_missing": true},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected = []string{"conan", "install", ".", "--build", "missing"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
 }
 
 func TestConanAdd(t *testing.T) {
@@ -2717,6 +3785,21 @@ func TestLuarocksAdd(t *testing.T) {
 	}
 }
 
+func TestLuarocksAddLocal(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("luarocks", "add", CommandInput{
+		Args:  map[string]string{"package": "luasocket"},
+		Flags: map[string]any{"user_install": true},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"luarocks", "install", "luasocket", "--local"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
 func TestLuarocksRemove(t *testing.T) {
 	tr := loadTranslator(t)
 	cmd, err := tr.BuildCommand("luarocks", "remove", CommandInput{
@@ -2797,6 +3880,34 @@ func TestShardsInstallFrozen(t *testing.T) {
 	}
 }
 
+func TestShardsAdd(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("shards", "add", CommandInput{
+		Args: map[string]string{"package": "kemal"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"shards", "add", "kemal"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestShardsAddWithURL(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("shards", "add", CommandInput{
+		Args: map[string]string{"package": "kemal", "url": "https://github.com/kemalcr/kemal.git"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"shards", "add", "kemal", "https://github.com/kemalcr/kemal.git"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
 func TestShardsList(t *testing.T) {
 	tr := loadTranslator(t)
 	cmd, err := tr.BuildCommand("shards", "list", CommandInput{})
@@ -2837,6 +3948,18 @@ func TestShardsUpdate(t *testing.T) {
 
 // --- nimble tests ---
 
+func TestNimbleDevelop(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("nimble", "develop", CommandInput{})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"nimble", "develop"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
 func TestNimbleInstall(t *testing.T) {
 	tr := loadTranslator(t)
 	cmd, err := tr.BuildCommand("nimble", "install", CommandInput{})
@@ -2941,172 +4064,650 @@ func TestLeinOutdated(t *testing.T) {
 	}
 }
 
-// --- cpanm tests ---
+func TestLeinAddUnsupported(t *testing.T) {
+	tr := loadTranslator(t)
+	for _, op := range []string{"add", "remove"} {
+		_, err := tr.BuildCommand("lein", op, CommandInput{
+			Args: map[string]string{"package": "cheshire"},
+		})
+		if !errors.Is(err, ErrUnsupportedOperation) {
+			t.Fatalf("%s: expected ErrUnsupportedOperation, got %v", op, err)
+		}
+		if !strings.Contains(err.Error(), "project.clj") {
+			t.Errorf("%s: expected a hint about project.clj, got %q", op, err)
+		}
+	}
+}
 
-func TestCpanmInstall(t *testing.T) {
+// --- clojure tests ---
+
+func TestClojureInstall(t *testing.T) {
 	tr := loadTranslator(t)
-	cmd, err := tr.BuildCommand("cpanm", "install", CommandInput{})
+	cmd, err := tr.BuildCommand("clojure", "install", CommandInput{})
 	if err != nil {
 		t.Fatalf("BuildCommand failed: %v", err)
 	}
-	expected := []string{"cpanm", "--installdeps", "."}
+	expected := []string{"clj", "-P"}
 	if !reflect.DeepEqual(cmd, expected) {
 		t.Errorf("got %v, want %v", cmd, expected)
 	}
 }
 
-func TestCpanmAdd(t *testing.T) {
+func TestClojureList(t *testing.T) {
 	tr := loadTranslator(t)
-	cmd, err := tr.BuildCommand("cpanm", "add", CommandInput{
-		Args: map[string]string{"package": "Moose"},
-	})
+	cmd, err := tr.BuildCommand("clojure", "list", CommandInput{})
 	if err != nil {
 		t.Fatalf("BuildCommand failed: %v", err)
 	}
-	expected := []string{"cpanm", "--install", "Moose"}
+	expected := []string{"clj", "-Sdeps", "{}", "-Stree"}
 	if !reflect.DeepEqual(cmd, expected) {
 		t.Errorf("got %v, want %v", cmd, expected)
 	}
 }
 
-func TestCpanmUpdate(t *testing.T) {
+func TestClojureOutdated(t *testing.T) {
 	tr := loadTranslator(t)
-	cmd, err := tr.BuildCommand("cpanm", "update", CommandInput{
-		Args: map[string]string{"package": "Moose"},
-	})
+	cmd, err := tr.BuildCommand("clojure", "outdated", CommandInput{})
 	if err != nil {
 		t.Fatalf("BuildCommand failed: %v", err)
 	}
-	expected := []string{"cpanm", "--install", "Moose"}
+	expected := []string{"clj", "-M:outdated"}
 	if !reflect.DeepEqual(cmd, expected) {
 		t.Errorf("got %v, want %v", cmd, expected)
 	}
 }
 
-// --- path command tests ---
+func TestClojureAddUnsupported(t *testing.T) {
+	tr := loadTranslator(t)
+	for _, op := range []string{"add", "remove"} {
+		_, err := tr.BuildCommand("clojure", op, CommandInput{
+			Args: map[string]string{"package": "org.clojure/data.json"},
+		})
+		if !errors.Is(err, ErrUnsupportedOperation) {
+			t.Fatalf("%s: expected ErrUnsupportedOperation, got %v", op, err)
+		}
+		if !strings.Contains(err.Error(), "deps.edn") {
+			t.Errorf("%s: expected a hint about deps.edn, got %q", op, err)
+		}
+	}
+}
 
-func TestNpmPath(t *testing.T) {
+// --- cpanm tests ---
+
+func TestCpanmInstall(t *testing.T) {
 	tr := loadTranslator(t)
-	cmd, err := tr.BuildCommand("npm", "path", CommandInput{
-		Args: map[string]string{"package": "lodash"},
-	})
+	cmd, err := tr.BuildCommand("cpanm", "install", CommandInput{})
 	if err != nil {
 		t.Fatalf("BuildCommand failed: %v", err)
 	}
-	expected := []string{"npm", "ls", "lodash", "--parseable"}
+	expected := []string{"cpanm", "--installdeps", "."}
 	if !reflect.DeepEqual(cmd, expected) {
 		t.Errorf("got %v, want %v", cmd, expected)
 	}
 }
 
-func TestPnpmPath(t *testing.T) {
+func TestCpanmAdd(t *testing.T) {
 	tr := loadTranslator(t)
-	cmd, err := tr.BuildCommand("pnpm", "path", CommandInput{
-		Args: map[string]string{"package": "lodash"},
+	cmd, err := tr.BuildCommand("cpanm", "add", CommandInput{
+		Args: map[string]string{"package": "Moose"},
 	})
 	if err != nil {
 		t.Fatalf("BuildCommand failed: %v", err)
 	}
-	expected := []string{"pnpm", "list", "lodash", "--parseable", "--depth", "0"}
+	expected := []string{"cpanm", "--install", "Moose"}
 	if !reflect.DeepEqual(cmd, expected) {
 		t.Errorf("got %v, want %v", cmd, expected)
 	}
 }
 
-func TestBundlerPath(t *testing.T) {
+func TestCpanmRemove(t *testing.T) {
 	tr := loadTranslator(t)
-	cmd, err := tr.BuildCommand("bundler", "path", CommandInput{
-		Args: map[string]string{"package": "rails"},
+	cmd, err := tr.BuildCommand("cpanm", "remove", CommandInput{
+		Args: map[string]string{"package": "Moose"},
 	})
 	if err != nil {
 		t.Fatalf("BuildCommand failed: %v", err)
 	}
-	expected := []string{"bundle", "info", "rails", "--path"}
+	expected := []string{"cpanm", "--uninstall", "Moose"}
 	if !reflect.DeepEqual(cmd, expected) {
 		t.Errorf("got %v, want %v", cmd, expected)
 	}
 }
 
-func TestPipPath(t *testing.T) {
+func TestCpanmList(t *testing.T) {
 	tr := loadTranslator(t)
-	cmd, err := tr.BuildCommand("pip", "path", CommandInput{
-		Args: map[string]string{"package": "requests"},
+	cmd, err := tr.BuildCommand("cpanm", "list", CommandInput{
+		Args: map[string]string{"package": "Moose"},
 	})
 	if err != nil {
 		t.Fatalf("BuildCommand failed: %v", err)
 	}
-	expected := []string{"pip", "show", "requests"}
+	expected := []string{"cpanm", "--info", "Moose"}
 	if !reflect.DeepEqual(cmd, expected) {
 		t.Errorf("got %v, want %v", cmd, expected)
 	}
 }
 
-func TestUvPath(t *testing.T) {
+func TestCpanmUpdate(t *testing.T) {
 	tr := loadTranslator(t)
-	cmd, err := tr.BuildCommand("uv", "path", CommandInput{
-		Args: map[string]string{"package": "requests"},
+	cmd, err := tr.BuildCommand("cpanm", "update", CommandInput{
+		Args: map[string]string{"package": "Moose"},
 	})
 	if err != nil {
 		t.Fatalf("BuildCommand failed: %v", err)
 	}
-	expected := []string{"uv", "pip", "show", "requests"}
+	expected := []string{"cpanm", "--install", "Moose"}
 	if !reflect.DeepEqual(cmd, expected) {
 		t.Errorf("got %v, want %v", cmd, expected)
 	}
 }
 
-func TestGomodPath(t *testing.T) {
+// --- scoop tests ---
+
+func TestScoopInstall(t *testing.T) {
 	tr := loadTranslator(t)
-	cmd, err := tr.BuildCommand("gomod", "path", CommandInput{
-		Args: map[string]string{"package": "github.com/stretchr/testify"},
-	})
+	cmd, err := tr.BuildCommand("scoop", "install", CommandInput{})
 	if err != nil {
 		t.Fatalf("BuildCommand failed: %v", err)
 	}
-	expected := []string{"go", "list", "-m", "-json", "github.com/stretchr/testify"}
+	expected := []string{"scoop", "install"}
 	if !reflect.DeepEqual(cmd, expected) {
 		t.Errorf("got %v, want %v", cmd, expected)
 	}
 }
 
-func TestCargoPath(t *testing.T) {
+func TestScoopAdd(t *testing.T) {
 	tr := loadTranslator(t)
-	// Cargo path uses extraction_only for package, so it shouldn't appear in command
-	cmd, err := tr.BuildCommand("cargo", "path", CommandInput{
-		Args: map[string]string{"package": "serde"},
+	cmd, err := tr.BuildCommand("scoop", "add", CommandInput{
+		Args: map[string]string{"package": "ripgrep"},
 	})
 	if err != nil {
 		t.Fatalf("BuildCommand failed: %v", err)
 	}
-	expected := []string{"cargo", "metadata", "--format-version", "1"}
+	expected := []string{"scoop", "install", "ripgrep"}
 	if !reflect.DeepEqual(cmd, expected) {
 		t.Errorf("got %v, want %v", cmd, expected)
 	}
 }
 
-func TestComposerPath(t *testing.T) {
+func TestScoopUpdate(t *testing.T) {
 	tr := loadTranslator(t)
-	cmd, err := tr.BuildCommand("composer", "path", CommandInput{
-		Args: map[string]string{"package": "monolog/monolog"},
+	cmd, err := tr.BuildCommand("scoop", "update", CommandInput{
+		Args: map[string]string{"package": "ripgrep"},
 	})
 	if err != nil {
 		t.Fatalf("BuildCommand failed: %v", err)
 	}
-	expected := []string{"composer", "show", "monolog/monolog", "--path"}
+	expected := []string{"scoop", "update", "ripgrep"}
 	if !reflect.DeepEqual(cmd, expected) {
 		t.Errorf("got %v, want %v", cmd, expected)
 	}
 }
 
-func TestBrewPath(t *testing.T) {
+// --- flatpak tests ---
+
+func TestFlatpakInstall(t *testing.T) {
 	tr := loadTranslator(t)
-	cmd, err := tr.BuildCommand("brew", "path", CommandInput{
-		Args: map[string]string{"package": "git"},
-	})
+	cmd, err := tr.BuildCommand("flatpak", "install", CommandInput{})
 	if err != nil {
 		t.Fatalf("BuildCommand failed: %v", err)
 	}
-	expected := []string{"brew", "--prefix", "git"}
+	expected := []string{"flatpak", "install", "--noninteractive"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestFlatpakAdd(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("flatpak", "add", CommandInput{
+		Args: map[string]string{"package": "org.gimp.GIMP"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"flatpak", "install", "--noninteractive", "org.gimp.GIMP"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestFlatpakAddFromRemote(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("flatpak", "add", CommandInput{
+		Args: map[string]string{"package": "org.gimp.GIMP", "remote": "flathub"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"flatpak", "install", "--noninteractive", "flathub", "org.gimp.GIMP"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestFlatpakUpdate(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("flatpak", "update", CommandInput{
+		Args: map[string]string{"package": "org.gimp.GIMP"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"flatpak", "update", "--noninteractive", "org.gimp.GIMP"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestFlatpakSearch(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("flatpak", "search", CommandInput{
+		Args: map[string]string{"query": "gimp"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"flatpak", "search", "--columns=application,version,description", "gimp"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+// --- apt tests ---
+
+func TestAptInstall(t *testing.T) {
+	tr := loadTranslator(t)
+	cmds, meta, err := tr.BuildCommands("apt", "install", CommandInput{})
+	if err != nil {
+		t.Fatalf("BuildCommands failed: %v", err)
+	}
+	expected := [][]string{
+		{"apt-get", "update"},
+		{"apt-get", "install", "--yes"},
+	}
+	if !reflect.DeepEqual(cmds, expected) {
+		t.Errorf("got %v, want %v", cmds, expected)
+	}
+	if meta.Steps[0].Label != "refresh package index" {
+		t.Errorf("got label %q, want %q", meta.Steps[0].Label, "refresh package index")
+	}
+
+	primary, err := tr.BuildPrimaryCommand("apt", "install", CommandInput{})
+	if err != nil {
+		t.Fatalf("BuildPrimaryCommand failed: %v", err)
+	}
+	if !reflect.DeepEqual(primary, expected[1]) {
+		t.Errorf("primary: got %v, want %v", primary, expected[1])
+	}
+	chain, _, err := tr.BuildChainCommands("apt", "install", CommandInput{})
+	if err != nil {
+		t.Fatalf("BuildChainCommands failed: %v", err)
+	}
+	if len(chain) != 0 {
+		t.Errorf("expected no steps after install, got %v", chain)
+	}
+}
+
+func TestAptAdd(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("apt", "add", CommandInput{
+		Args: map[string]string{"package": "curl"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"apt-get", "install", "--yes", "curl"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestAptUpdate(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("apt", "update", CommandInput{
+		Args: map[string]string{"package": "curl"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"apt-get", "install", "--yes", "--only-upgrade", "curl"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestAptOutdated(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("apt", "outdated", CommandInput{})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"apt", "list", "--upgradable"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+// --- snap tests ---
+
+func TestSnapAdd(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("snap", "add", CommandInput{
+		Args: map[string]string{"package": "vlc"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"snap", "install", "vlc"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestSnapUpdate(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("snap", "update", CommandInput{
+		Args: map[string]string{"package": "vlc"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"snap", "refresh", "vlc"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestSnapSearch(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("snap", "search", CommandInput{
+		Args: map[string]string{"query": "vlc"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"snap", "find", "vlc"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestSnapInstallUnsupported(t *testing.T) {
+	tr := loadTranslator(t)
+	_, err := tr.BuildCommand("snap", "install", CommandInput{})
+	if !errors.Is(err, ErrUnsupportedOperation) {
+		t.Errorf("expected ErrUnsupportedOperation, got %v", err)
+	}
+}
+
+func TestSnapOutdatedExtract(t *testing.T) {
+	def, _ := loadTranslator(t).Definition("snap")
+	output := "Name  Version  Rev   Size   Publisher   Notes\n" +
+		"vlc   3.0.20   3777  341MB  videolan✓   -\n"
+	got, err := ExtractPath(output, def.Commands["outdated"].Extract, "vlc")
+	if err != nil {
+		t.Fatalf("ExtractPath failed: %v", err)
+	}
+	if got != "3.0.20" {
+		t.Errorf("got %q, want %q", got, "3.0.20")
+	}
+}
+
+// --- pixi tests ---
+
+func TestPixiInstall(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("pixi", "install", CommandInput{})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"pixi", "install"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestPixiAdd(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("pixi", "add", CommandInput{
+		Args: map[string]string{"package": "numpy"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"pixi", "add", "numpy"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestPixiRemove(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("pixi", "remove", CommandInput{
+		Args: map[string]string{"package": "numpy"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"pixi", "remove", "numpy"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestPixiUpdate(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("pixi", "update", CommandInput{
+		Args: map[string]string{"package": "numpy"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"pixi", "update", "numpy"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestPixiList(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("pixi", "list", CommandInput{})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"pixi", "list", "--json"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestPixiOutdated(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("pixi", "outdated", CommandInput{})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"pixi", "list", "--outdated"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+// --- mint tests ---
+
+func TestMintInstall(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("mint", "install", CommandInput{})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"mint", "bootstrap"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestMintAdd(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("mint", "add", CommandInput{
+		Args: map[string]string{"package": "realm/SwiftLint", "version": "0.54.0"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"mint", "install", "realm/SwiftLint@0.54.0"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestMintList(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("mint", "list", CommandInput{})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"mint", "list"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestMintRemoveUnsupported(t *testing.T) {
+	tr := loadTranslator(t)
+	_, err := tr.BuildCommand("mint", "remove", CommandInput{
+		Args: map[string]string{"package": "realm/SwiftLint"},
+	})
+	if !errors.Is(err, ErrUnsupportedOperation) {
+		t.Errorf("expected ErrUnsupportedOperation, got %v", err)
+	}
+}
+
+// --- path command tests ---
+
+func TestNpmPath(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("npm", "path", CommandInput{
+		Args: map[string]string{"package": "lodash"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"npm", "ls", "lodash", "--parseable"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestPnpmPath(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("pnpm", "path", CommandInput{
+		Args: map[string]string{"package": "lodash"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"pnpm", "list", "lodash", "--parseable", "--depth", "0"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestBundlerPath(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("bundler", "path", CommandInput{
+		Args: map[string]string{"package": "rails"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"bundle", "info", "rails", "--path"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestPipPath(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("pip", "path", CommandInput{
+		Args: map[string]string{"package": "requests"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"pip", "show", "requests"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestUvPath(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("uv", "path", CommandInput{
+		Args: map[string]string{"package": "requests"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"uv", "pip", "show", "requests"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestGomodPath(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("gomod", "path", CommandInput{
+		Args: map[string]string{"package": "github.com/stretchr/testify"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"go", "list", "-m", "-json", "github.com/stretchr/testify"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestCargoPath(t *testing.T) {
+	tr := loadTranslator(t)
+	// Cargo path uses extraction_only for package, so it shouldn't appear in command
+	cmd, err := tr.BuildCommand("cargo", "path", CommandInput{
+		Args: map[string]string{"package": "serde"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"cargo", "metadata", "--format-version", "1"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestComposerPath(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("composer", "path", CommandInput{
+		Args: map[string]string{"package": "monolog/monolog"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"composer", "show", "monolog/monolog", "--path"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestBrewPath(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("brew", "path", CommandInput{
+		Args: map[string]string{"package": "git"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"brew", "--prefix", "git"}
 	if !reflect.DeepEqual(cmd, expected) {
 		t.Errorf("got %v, want %v", cmd, expected)
 	}
@@ -3140,6 +4741,127 @@ func TestDenoPath(t *testing.T) {
 	}
 }
 
+func TestDenoRun(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("deno", "run", CommandInput{
+		Args: map[string]string{"script": "test"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"deno", "task", "test"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestNpmVendor(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("npm", "vendor", CommandInput{})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"npm", "pack", "--pack-destination", "vendor/"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+
+	cmd, err = tr.BuildCommand("npm", "vendor", CommandInput{
+		Args: map[string]string{"vendor_dir": "dist/offline"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected = []string{"npm", "pack", "--pack-destination", "dist/offline"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestNpmRun(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("npm", "run", CommandInput{
+		Args:  map[string]string{"script": "test"},
+		Extra: []string{"--watch"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"npm", "run", "test", "--", "--watch"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+
+	// A separator the caller already added isn't doubled
+	cmd, err = tr.BuildCommand("npm", "run", CommandInput{
+		Args:  map[string]string{"script": "test"},
+		Extra: []string{"--", "--watch"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestMixRun(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("mix", "run", CommandInput{
+		Args: map[string]string{"script": "ecto.migrate"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"mix", "ecto.migrate"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestPoetryRun(t *testing.T) {
+	tr := loadTranslator(t)
+	cmd, err := tr.BuildCommand("poetry", "run", CommandInput{
+		Args:  map[string]string{"script": "pytest"},
+		Extra: []string{"-x"},
+	})
+	if err != nil {
+		t.Fatalf("BuildCommand failed: %v", err)
+	}
+	expected := []string{"poetry", "run", "pytest", "-x"}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("got %v, want %v", cmd, expected)
+	}
+}
+
+func TestRunScript(t *testing.T) {
+	tr := loadTranslator(t)
+	tests := []struct {
+		manager  string
+		expected []string
+	}{
+		{"npm", []string{"npm", "run", "build"}},
+		{"pnpm", []string{"pnpm", "run", "build"}},
+		{"yarn", []string{"yarn", "run", "build"}},
+		{"bun", []string{"bun", "run", "build"}},
+		{"mix", []string{"mix", "build"}},
+		{"poetry", []string{"poetry", "run", "build"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.manager, func(t *testing.T) {
+			cmd, err := tr.BuildCommand(tt.manager, "run", CommandInput{
+				Args: map[string]string{"script": "build"},
+			})
+			if err != nil {
+				t.Fatalf("BuildCommand failed: %v", err)
+			}
+			if !reflect.DeepEqual(cmd, tt.expected) {
+				t.Errorf("got %v, want %v", cmd, tt.expected)
+			}
+		})
+	}
+}
+
 func TestNimblePath(t *testing.T) {
 	tr := loadTranslator(t)
 	cmd, err := tr.BuildCommand("nimble", "path", CommandInput{
@@ -3356,3 +5078,74 @@ func TestPipVendor(t *testing.T) {
 		t.Errorf("got %v, want %v", cmd, expected)
 	}
 }
+
+// --- clean tests ---
+
+func TestCleanCommands(t *testing.T) {
+	tr := loadTranslator(t)
+	tests := []struct {
+		manager  string
+		expected []string
+	}{
+		{"npm", []string{"npm", "cache", "clean", "--force"}},
+		{"pnpm", []string{"pnpm", "store", "prune"}},
+		{"yarn", []string{"yarn", "cache", "clean"}},
+		{"bundler", []string{"bundle", "clean", "--force"}},
+		{"cargo", []string{"cargo", "clean"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.manager, func(t *testing.T) {
+			cmd, err := tr.BuildCommand(tt.manager, "clean", CommandInput{})
+			if err != nil {
+				t.Fatalf("BuildCommand failed: %v", err)
+			}
+			if !reflect.DeepEqual(cmd, tt.expected) {
+				t.Errorf("got %v, want %v", cmd, tt.expected)
+			}
+		})
+	}
+}
+
+// --- arg ordering tests ---
+
+func TestSortArgsByPosition(t *testing.T) {
+	defs := map[string]definitions.Arg{
+		"version": {Flag: "--version"},
+		"url":     {Position: 1},
+		"package": {Position: 0},
+		"chart":   {Position: 1},
+		"extra":   {Flag: "--extra"},
+		"channel": {Flag: "-c", Position: -1},
+	}
+
+	for i := 0; i < 20; i++ {
+		var names []string
+		for _, entry := range sortArgsByPosition(defs) {
+			names = append(names, entry.name)
+		}
+		expected := []string{"channel", "package", "chart", "url", "extra", "version"}
+		if !reflect.DeepEqual(names, expected) {
+			t.Fatalf("got %v, want %v", names, expected)
+		}
+	}
+}
+
+func TestHelmAddDeterministic(t *testing.T) {
+	tr := loadTranslator(t)
+	expected := []string{"helm", "repo", "add", "bitnami", "https://charts.bitnami.com/bitnami"}
+	for i := 0; i < 20; i++ {
+		cmd, err := tr.BuildCommand("helm", "add", CommandInput{
+			Args: map[string]string{
+				"package": "bitnami",
+				"url":     "https://charts.bitnami.com/bitnami",
+			},
+		})
+		if err != nil {
+			t.Fatalf("BuildCommand failed: %v", err)
+		}
+		if !reflect.DeepEqual(cmd, expected) {
+			t.Fatalf("got %v, want %v", cmd, expected)
+		}
+	}
+}
@@ -1,7 +1,11 @@
 package managers
 
 import (
+	"fmt"
 	"regexp"
+	"sort"
+
+	"github.com/git-pkgs/managers/definitions"
 )
 
 var defaultValidators = map[string]*regexp.Regexp{
@@ -49,3 +53,106 @@ func ValidatePackageName(validatorName, name string) error {
 
 	return nil
 }
+
+// Validate checks input against an operation without building the command.
+// Unlike BuildCommand, which stops at the first problem, it reports every
+// missing required arg, arg value rejected by a validator, flag the
+// operation doesn't support and disallowed extra arg. The before and then
+// steps chained around the operation are checked too, since BuildCommands
+// builds them with the same input. Each problem is a separate error that
+// can be inspected with errors.Is and errors.As. It returns nil if the
+// input is valid.
+func (t *Translator) Validate(managerName, operation string, input CommandInput) []error {
+	def, ok := t.definitions[managerName]
+	if !ok {
+		return []error{fmt.Errorf("unknown manager: %s", managerName)}
+	}
+
+	cmd, ok := def.Commands[operation]
+	if !ok || cmd.Unsupported != "" {
+		return []error{unsupportedOperation(def, cmd.Unsupported)}
+	}
+
+	v := &inputValidator{
+		translator: t,
+		input:      input,
+		seen:       make(map[string]bool),
+		flags:      make(map[string]bool),
+	}
+	v.command(def, operation, cmd, 0)
+
+	var unsupported []string
+	for name, val := range input.Flags {
+		if isTruthy(val) && !v.flags[name] {
+			unsupported = append(unsupported, name)
+		}
+	}
+	sort.Strings(unsupported)
+	for _, name := range unsupported {
+		v.errs = append(v.errs, fmt.Errorf("%w: %s", ErrUnsupportedOption, name))
+	}
+
+	v.errs = append(v.errs, extraErrors(def.SafeExtraArgs[operation], input.Extra)...)
+
+	return v.errs
+}
+
+// inputValidator collects Validate's errors across a command chain.
+type inputValidator struct {
+	translator *Translator
+	input      CommandInput
+	errs       []error
+	seen       map[string]bool // error messages already reported by another step
+	flags      map[string]bool // flags some step of the chain accepts
+}
+
+func (v *inputValidator) add(err error) {
+	if v.seen[err.Error()] {
+		return
+	}
+	v.seen[err.Error()] = true
+	v.errs = append(v.errs, err)
+}
+
+// command checks cmd's args and records its flags, then does the same for
+// its before and then steps in the order BuildCommands builds them.
+func (v *inputValidator) command(def *definitions.Definition, operation string, cmd definitions.Command, depth int) {
+	if depth > maxChainDepth {
+		v.add(ErrCircularCommandChain{Manager: def.Name, Operation: operation, Depth: depth})
+		return
+	}
+
+	for _, before := range cmd.Before {
+		v.command(def, operation, before, depth+1)
+	}
+
+	argVals := resolveArgs(cmd.Args, v.input.Args)
+	for _, entry := range sortArgsByPosition(cmd.Args) {
+		if entry.argDef.ExtractionOnly {
+			continue
+		}
+		val, provided := argVals[entry.name]
+		if !provided {
+			if entry.argDef.Required {
+				v.add(ErrMissingArgument{Argument: entry.name})
+			}
+			continue
+		}
+		if entry.argDef.Validate != "" {
+			if err := v.translator.validate(entry.argDef.Validate, val); err != nil {
+				v.add(err)
+			}
+		}
+	}
+
+	for name := range cmd.Flags {
+		v.flags[name] = true
+	}
+	for name := range cmd.BaseOverrides {
+		v.flags[name] = true
+	}
+
+	for _, next := range cmd.Then {
+		v.command(def, operation, next, depth+1)
+	}
+}
@@ -0,0 +1,123 @@
+package managers
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/git-pkgs/managers/definitions"
+)
+
+func TestTranslatorValidate(t *testing.T) {
+	tr := safeExtraTranslator()
+	tr.RegisterValidator("cargo_crate", &definitions.Validator{MaxLength: 8})
+	def, _ := tr.Definition("cargo")
+	def.Commands["add"] = definitions.Command{
+		Base: []string{"add"},
+		Args: map[string]definitions.Arg{
+			"package": {Position: 0, Required: true, Validate: "cargo_crate"},
+			"version": {Flag: "--vers", Required: true},
+		},
+		Flags: map[string]definitions.Flag{
+			"dev": {Values: []definitions.FlagValue{{Literal: "--dev"}}},
+		},
+	}
+
+	errs := tr.Validate("cargo", "add", CommandInput{
+		Args:  map[string]string{"package": "much-too-long"},
+		Flags: map[string]any{"dev": true, "optional": true, "frozen": false},
+		Extra: []string{"--config", "evil.toml", "--locked"},
+	})
+	if len(errs) != 5 {
+		t.Fatalf("expected 5 validation errors, got %d: %v", len(errs), errs)
+	}
+	err := errors.Join(errs...)
+
+	var missing ErrMissingArgument
+	if !errors.As(err, &missing) || missing.Argument != "version" {
+		t.Errorf("expected missing version argument, got %v", err)
+	}
+	var invalid ErrInvalidPackageName
+	if !errors.As(err, &invalid) || invalid.Name != "much-too-long" {
+		t.Errorf("expected invalid package name, got %v", err)
+	}
+	if !errors.Is(err, ErrUnsupportedOption) {
+		t.Errorf("expected ErrUnsupportedOption, got %v", err)
+	}
+
+	lines := strings.Split(err.Error(), "\n")
+	expected := []string{
+		"invalid package name \"much-too-long\": exceeds maximum length of 8",
+		"missing required argument: version",
+		"option not supported by this manager: optional",
+		"option not supported by this manager: --config",
+		"option not supported by this manager: evil.toml",
+	}
+	if strings.Join(lines, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("got errors:\n%s\nwant:\n%s", err, strings.Join(expected, "\n"))
+	}
+}
+
+func TestTranslatorValidateValidInput(t *testing.T) {
+	tr := loadTranslator(t)
+	errs := tr.Validate("npm", "add", CommandInput{
+		Args:  map[string]string{"package": "lodash"},
+		Flags: map[string]any{"dev": true},
+	})
+	if errs != nil {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+}
+
+func TestTranslatorValidateChainedSteps(t *testing.T) {
+	tr := NewTranslator()
+	tr.Register(&definitions.Definition{
+		Name:   "testpkg",
+		Binary: "testpkg",
+		Commands: map[string]definitions.Command{
+			"add": {
+				Base: []string{"add"},
+				Args: map[string]definitions.Arg{
+					"package": {Position: 0, Required: true},
+				},
+				Before: []definitions.Command{{
+					Base: []string{"login"},
+					Args: map[string]definitions.Arg{
+						"registry": {Flag: "--registry", Required: true},
+					},
+				}},
+				Then: []definitions.Command{{
+					Base: []string{"lock"},
+					Args: map[string]definitions.Arg{
+						"package": {Position: 0, Required: true},
+					},
+					Flags: map[string]definitions.Flag{
+						"offline": {Values: []definitions.FlagValue{{Literal: "--offline"}}},
+					},
+				}},
+			},
+		},
+	})
+
+	// The then step's flag is supported, and its missing package is only
+	// reported once
+	errs := tr.Validate("testpkg", "add", CommandInput{
+		Flags: map[string]any{"offline": true},
+	})
+	expected := []error{
+		ErrMissingArgument{Argument: "registry"},
+		ErrMissingArgument{Argument: "package"},
+	}
+	if !reflect.DeepEqual(errs, expected) {
+		t.Errorf("got %v, want %v", errs, expected)
+	}
+}
+
+func TestTranslatorValidateUnsupportedOperation(t *testing.T) {
+	tr := loadTranslator(t)
+	errs := tr.Validate("rebar3", "add", CommandInput{})
+	if len(errs) != 1 || !errors.Is(errs[0], ErrUnsupportedOperation) {
+		t.Errorf("expected ErrUnsupportedOperation, got %v", errs)
+	}
+}